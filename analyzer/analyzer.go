@@ -0,0 +1,379 @@
+//Package analyzer provides gounexport as golang.org/x/tools/go/analysis
+//Analyzer, so it could be run by go vet, multichecker or gopls.
+//
+//go/analysis checks packages one by one starting from dependencies.
+//Packages that are using a definition are checked after the package of
+//the definition, so it can't be reported in its own package. Instead, the
+//analyzer exports facts:
+//
+//  - candidateFact for each exported definition. It contains all edits
+//    that Unexport would make in the package of the definition;
+//  - usagesFact for each package. It contains IDs of definitions from
+//    other packages that are used in the package.
+//
+//Unused definitions are reported when a root package is checked. By default,
+//roots are main packages, use -roots flag to set them explicitly. Root package
+//has facts of all packages that it imports transitively, so definition is
+//reported if none of them is using it. Each diagnostic has a suggested fix
+//that renames the definition, if sources of the package are available.
+//
+//For example, to run it with go vet:
+//  go build -o gounexport-vet github.com/dooman87/gounexport/cmd/gounexport-vet
+//  go vet -vettool=$(pwd)/gounexport-vet ./...
+//
+//Be aware, that usages are collected only from packages that are imported by
+//the root. If there are several roots, then each of them reports definitions
+//that it doesn't use. Use -roots flag with a package that imports all
+//consumers to avoid false positives.
+package analyzer
+
+import (
+	"fmt"
+	"go/build"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/dooman87/gounexport"
+	"github.com/dooman87/gounexport/edit"
+)
+
+const doc = `report exported definitions that are not used outside of their packages
+
+Definitions are reported when root package is checked. Root packages are
+main packages or packages from -roots flag. Usages are collected from all
+packages that are imported by the root.`
+
+//Analyzer reports exported definitions that are not used
+//outside of their packages and suggests to unexport them.
+var Analyzer = &analysis.Analyzer{
+	Name:      "gounexport",
+	Doc:       doc,
+	Run:       run,
+	FactTypes: []analysis.Fact{new(candidateFact), new(usagesFact)},
+}
+
+var (
+	roots       string
+	scope       string
+	exclude     string
+	kinds       string
+	naming      string
+	ignoreTests bool
+)
+
+func init() {
+	Analyzer.Flags.StringVar(&roots, "roots", "",
+		"Comma separated list of packages where unused definitions are reported. Main packages are used by default")
+	Analyzer.Flags.StringVar(&scope, "scope", "",
+		"Package path prefix of definitions that could be unexported. All packages except standard library by default")
+	Analyzer.Flags.StringVar(&exclude, "exclude", "Test*",
+		"Regular expression for names of definitions that shouldn't be unexported")
+	Analyzer.Flags.StringVar(&kinds, "kinds", "",
+		"Comma separated kinds of definitions to report. All kinds are reported by default")
+	Analyzer.Flags.StringVar(&naming, "naming", string(gounexport.NamingInitialisms),
+		"Naming strategy for new names: first or initialisms")
+	Analyzer.Flags.BoolVar(&ignoreTests, "ignoretests", false,
+		"If set, then usages from test files are not counted")
+}
+
+//candidateFact marks exported definition that could be
+//unexported if it's not used in other packages.
+type candidateFact struct {
+	//ID is an identifier of the definition, see gounexport.Definition
+	ID string
+	//Name is a full name of the definition, see gounexport.Definition
+	Name string
+	//NewName is a name of the definition after unexporting
+	NewName string
+	//Interfaces are IDs of implemented interfaces and their methods.
+	//Definition is used if any of them is used.
+	Interfaces []string
+	//Edits are all renames in the package of the definition
+	Edits []*edit.Edit
+	//Error is a reason why the definition can't be unexported
+	Error string
+	//FileSizes are sizes of changed files. Edits are applied only
+	//if the file set of the root package contains the same files.
+	FileSizes map[string]int
+}
+
+func (*candidateFact) AFact() {}
+
+func (fact *candidateFact) String() string {
+	return "candidate " + fact.Name
+}
+
+//usagesFact contains IDs of candidates from other
+//packages that are used in the package.
+type usagesFact struct {
+	Used []string
+}
+
+func (*usagesFact) AFact() {}
+
+func (fact *usagesFact) String() string {
+	return fmt.Sprintf("used %v", fact.Used)
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	if len(pass.Files) == 0 || isInGoroot(pass.Fset.File(pass.Files[0].Pos()).Name()) {
+		return nil, nil
+	}
+
+	opts := new(gounexport.Options)
+	opts.IgnoreTestUsages = ignoreTests
+	if len(exclude) > 0 {
+		regex, err := regexp.Compile(exclude)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern: %v", err)
+		}
+		opts.Excludes = []*regexp.Regexp{regex}
+	}
+	if len(kinds) > 0 {
+		var err error
+		if opts.Kinds, err = gounexport.ParseKinds(strings.Split(kinds, ",")); err != nil {
+			return nil, err
+		}
+	}
+
+	if isInScope(pass.Pkg.Path()) {
+		strategy, err := gounexport.ParseNamingStrategy(naming)
+		if err != nil {
+			return nil, err
+		}
+		exportCandidates(pass, opts, strategy)
+	}
+	exportUsages(pass)
+
+	if isRoot(pass.Pkg) {
+		report(pass)
+	}
+	return nil, nil
+}
+
+//exportCandidates exports candidateFact for each exported
+//definition of the package.
+func exportCandidates(pass *analysis.Pass, opts *gounexport.Options, strategy gounexport.NamingStrategy) {
+	defs := gounexport.GetDefinitions(pass.TypesInfo, pass.Fset)
+	naming := gounexport.NewNaming()
+	naming.Strategy = strategy
+
+	objects := make(map[string]types.Object)
+	for ident, obj := range pass.TypesInfo.Defs {
+		if obj == nil || obj.Pkg() != pass.Pkg || !obj.Exported() {
+			continue
+		}
+		//Local types are not visible outside of the package
+		if obj.Parent() != nil && obj.Parent() != pass.Pkg.Scope() {
+			continue
+		}
+		objects[positionKey(pass.Fset.Position(ident.Pos()))] = obj
+	}
+
+	fileSizes := make(map[string]int)
+	for _, f := range pass.Files {
+		file := pass.Fset.File(f.Pos())
+		fileSizes[file.Name()] = file.Size()
+	}
+
+	//Other packages could use definitions only within the
+	//package, so all of them are candidates at this point.
+	for _, def := range gounexport.FindUnusedDefinitionsWithOptions(pass.Pkg.Path(), defs, opts) {
+		obj := objects[positionKey(token.Position{Filename: def.File, Offset: def.Offset})]
+		if obj == nil {
+			continue
+		}
+		if fact := newCandidateFact(def, defs, naming, fileSizes); fact != nil {
+			pass.ExportObjectFact(obj, fact)
+		}
+	}
+}
+
+//newCandidateFact returns nil if definition implements interfaces
+//from packages that are out of scope. Their usages are not tracked.
+func newCandidateFact(def *gounexport.Definition, defs map[string]*gounexport.Definition,
+	naming *gounexport.Naming, fileSizes map[string]int) *candidateFact {
+	fact := new(candidateFact)
+	fact.ID = def.ID
+	fact.Name = def.Name
+	for _, i := range def.Interfaces {
+		if i.Pkg == nil || isStandardPackage(i.Pkg.Path()) || !isInScope(i.Pkg.Path()) {
+			return nil
+		}
+		fact.Interfaces = append(fact.Interfaces, i.ID)
+	}
+
+	fact.FileSizes = make(map[string]int)
+	collect := func(file string, offset int, from string, to string) error {
+		fact.Edits = append(fact.Edits, &edit.Edit{File: file, Offset: offset, From: from, To: to})
+		fact.FileSizes[file] = fileSizes[file]
+		fact.NewName = to
+		return nil
+	}
+	if err := gounexport.UnexportWithNaming(def, defs, naming, collect); err != nil {
+		fact.Edits = nil
+		fact.Error = err.Error()
+	}
+	return fact
+}
+
+//exportUsages exports usagesFact with names of candidates
+//from other packages that are used in the package.
+func exportUsages(pass *analysis.Pass) {
+	used := make(map[string]bool)
+	for ident, obj := range pass.TypesInfo.Uses {
+		if obj.Pkg() == nil || obj.Pkg().Path() == pass.Pkg.Path() {
+			continue
+		}
+		if ignoreTests && strings.HasSuffix(pass.Fset.Position(ident.Pos()).Filename, "_test.go") {
+			continue
+		}
+		fact := new(candidateFact)
+		if pass.ImportObjectFact(origin(obj), fact) {
+			used[fact.ID] = true
+		}
+	}
+
+	fact := new(usagesFact)
+	for name := range used {
+		fact.Used = append(fact.Used, name)
+	}
+	sort.Strings(fact.Used)
+	pass.ExportPackageFact(fact)
+}
+
+//report reports all candidates that are not used by
+//the root package and packages that it imports.
+func report(pass *analysis.Pass) {
+	used := make(map[string]bool)
+	for _, f := range pass.AllPackageFacts() {
+		if fact, ok := f.Fact.(*usagesFact); ok {
+			for _, name := range fact.Used {
+				used[name] = true
+			}
+		}
+	}
+
+	var unused []analysis.ObjectFact
+	for _, f := range pass.AllObjectFacts() {
+		if fact, ok := f.Fact.(*candidateFact); ok && !isUsed(fact, used) {
+			unused = append(unused, f)
+		}
+	}
+	sort.Slice(unused, func(i, j int) bool {
+		return unused[i].Object.Pos() < unused[j].Object.Pos()
+	})
+
+	files := make(map[string]*token.File)
+	pass.Fset.Iterate(func(f *token.File) bool {
+		files[f.Name()] = f
+		return true
+	})
+
+	for _, f := range unused {
+		fact := f.Fact.(*candidateFact)
+		diagnostic := analysis.Diagnostic{
+			Pos:      f.Object.Pos(),
+			Category: "unused-export",
+			Message:  fmt.Sprintf("%s is exported, but not used outside of its package", fact.Name),
+		}
+		if len(fact.Error) > 0 {
+			diagnostic.Message += ", but can't be unexported: " + fact.Error
+		} else if fix, ok := suggestedFix(fact, files); ok {
+			diagnostic.SuggestedFixes = []analysis.SuggestedFix{fix}
+		}
+		pass.Report(diagnostic)
+	}
+}
+
+//origin returns generic object for objects of
+//instantiated types and functions, because facts
+//are exported for generic ones.
+func origin(obj types.Object) types.Object {
+	switch obj := obj.(type) {
+	case *types.Func:
+		return obj.Origin()
+	case *types.Var:
+		return obj.Origin()
+	}
+	return obj
+}
+
+func isUsed(fact *candidateFact, used map[string]bool) bool {
+	if used[fact.ID] {
+		return true
+	}
+	for _, i := range fact.Interfaces {
+		if used[i] {
+			return true
+		}
+	}
+	return false
+}
+
+//suggestedFix converts edits to text edits. It returns false if file set
+//doesn't contain sources of changed files, for example when dependencies
+//are loaded from export data.
+func suggestedFix(fact *candidateFact, files map[string]*token.File) (analysis.SuggestedFix, bool) {
+	fix := analysis.SuggestedFix{
+		Message: fmt.Sprintf("Rename %s to %s", fact.Name, fact.NewName),
+	}
+	added := make(map[string]bool)
+	for _, e := range fact.Edits {
+		file := files[e.File]
+		if file == nil || file.Size() != fact.FileSizes[e.File] {
+			return fix, false
+		}
+		//Unexport could rename the same usage twice
+		if key := positionKey(token.Position{Filename: e.File, Offset: e.Offset}); !added[key] {
+			added[key] = true
+			pos := file.Pos(e.Offset)
+			fix.TextEdits = append(fix.TextEdits, analysis.TextEdit{
+				Pos:     pos,
+				End:     pos + token.Pos(len(e.From)),
+				NewText: []byte(e.To),
+			})
+		}
+	}
+	return fix, len(fix.TextEdits) > 0
+}
+
+func isRoot(pkg *types.Package) bool {
+	if len(roots) == 0 {
+		return pkg.Name() == "main"
+	}
+	for _, root := range strings.Split(roots, ",") {
+		if strings.TrimSpace(root) == pkg.Path() {
+			return true
+		}
+	}
+	return false
+}
+
+func isInScope(pkg string) bool {
+	return strings.HasPrefix(pkg, scope)
+}
+
+//isStandardPackage returns true if the package is
+//in $GOROOT/src, e.g. fmt or net/http.
+func isStandardPackage(pkg string) bool {
+	info, err := os.Stat(filepath.Join(build.Default.GOROOT, "src", filepath.FromSlash(pkg)))
+	return err == nil && info.IsDir()
+}
+
+//isInGoroot returns true if the file is in $GOROOT/src
+func isInGoroot(file string) bool {
+	src := filepath.Join(build.Default.GOROOT, "src") + string(filepath.Separator)
+	return strings.HasPrefix(filepath.Clean(file), src)
+}
+
+func positionKey(pos token.Position) string {
+	return fmt.Sprintf("%s:%d", pos.Filename, pos.Offset)
+}
@@ -0,0 +1,158 @@
+package analyzer
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+var testFiles = map[string]string{
+	"example.com/lib/lib.go": `package lib
+
+type Used struct{}
+
+func (u *Used) Method() {}
+
+func (u *Used) Unused() {}
+
+func Func() {}
+
+func Unused() {}
+
+type List[T any] struct{}
+
+func (l *List[T]) Push(v T) {}
+
+func unexported() {
+	Unused()
+}
+`,
+	"example.com/app/main.go": `package main
+
+import "example.com/lib"
+
+func main() {
+	u := &lib.Used{}
+	u.Method()
+	lib.Func()
+	l := &lib.List[int]{}
+	l.Push(1)
+}
+`,
+}
+
+func TestAnalyzer(t *testing.T) {
+	diagnostics := analyze(t, "example.com/app")
+
+	expected := []string{
+		"example.com/lib.Used.Unused is exported, but not used outside of its package",
+		"example.com/lib.Unused is exported, but not used outside of its package",
+	}
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, but found %v", len(expected), diagnostics)
+	}
+	for i, d := range diagnostics {
+		if d.Message != expected[i] {
+			t.Errorf("expected [%s], but found [%s]", expected[i], d.Message)
+		}
+	}
+
+	//Declaration and usage in unexported()
+	fix := diagnostics[1].SuggestedFixes
+	if len(fix) != 1 || len(fix[0].TextEdits) != 2 {
+		t.Fatalf("expected fix with 2 edits, but found %v", fix)
+	}
+	for _, e := range fix[0].TextEdits {
+		if string(e.NewText) != "unused" || int(e.End-e.Pos) != len("Unused") {
+			t.Errorf("expected renaming to unused, but found %v", e)
+		}
+	}
+}
+
+func TestAnalyzerRoots(t *testing.T) {
+	roots = "example.com/lib"
+	defer func() { roots = "" }()
+
+	//Nothing is imported by the lib, so all definitions are unused
+	if diagnostics := analyze(t, "example.com/app"); len(diagnostics) != 7 {
+		t.Errorf("expected 7 diagnostics, but found %v", diagnostics)
+	}
+}
+
+func TestAnalyzerKinds(t *testing.T) {
+	kinds = "method"
+	defer func() { kinds = "" }()
+
+	diagnostics := analyze(t, "example.com/app")
+	if len(diagnostics) != 1 || !strings.HasPrefix(diagnostics[0].Message, "example.com/lib.Used.Unused ") {
+		t.Errorf("expected only unused method, but found %v", diagnostics)
+	}
+}
+
+func TestAnalyzerPathWithoutDot(t *testing.T) {
+	files := map[string]string{
+		"mysvc/svc.go": `package mysvc
+
+func Used() {}
+
+func Unused() {}
+`,
+		"mysvc/cmd/main.go": `package main
+
+import "mysvc"
+
+func main() {
+	mysvc.Used()
+}
+`,
+	}
+
+	//Packages are not from standard library, even if
+	//the first element of the path doesn't contain a dot
+	diagnostics := analyzeFiles(t, files, "mysvc/cmd")
+	if len(diagnostics) != 1 || !strings.HasPrefix(diagnostics[0].Message, "mysvc.Unused ") {
+		t.Errorf("expected mysvc.Unused to be reported, but found %v", diagnostics)
+	}
+}
+
+func analyze(t *testing.T, pkg string) []analysis.Diagnostic {
+	return analyzeFiles(t, testFiles, pkg)
+}
+
+func analyzeFiles(t *testing.T, files map[string]string, pkg string) []analysis.Diagnostic {
+	dir, cleanup, err := analysistest.WriteFiles(files)
+	if err != nil {
+		t.Fatalf("error while writing files: %v", err)
+	}
+	defer cleanup()
+
+	cfg := &packages.Config{
+		Mode: packages.LoadAllSyntax,
+		Dir:  dir,
+		Env:  append(os.Environ(), "GOPATH="+dir, "GO111MODULE=off", "GOPROXY=off"),
+	}
+	pkgs, err := packages.Load(cfg, pkg)
+	if err != nil {
+		t.Fatalf("error while loading packages: %v", err)
+	}
+	result, err := checker.Analyze([]*analysis.Analyzer{Analyzer}, pkgs, nil)
+	if err != nil {
+		t.Fatalf("error while analyzing: %v", err)
+	}
+
+	var diagnostics []analysis.Diagnostic
+	for action := range result.All() {
+		if action.Err != nil {
+			t.Fatalf("error while analyzing %s: %v", action.Package.PkgPath, action.Err)
+		}
+		if action.Analyzer == Analyzer {
+			diagnostics = append(diagnostics, action.Diagnostics...)
+		}
+	}
+	return diagnostics
+}
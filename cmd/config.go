@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/build"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/dooman87/gounexport/config"
+	"github.com/dooman87/gounexport/util"
+)
+
+//loadConfig loads configuration from the file. If file is empty, then
+//configuration is searched from the working directory up to the root.
+//Returns nil if there is no configuration file.
+func loadConfig(file string) (*config.Config, error) {
+	if len(file) == 0 {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		if file, err = config.Find(wd); err != nil || len(file) == 0 {
+			return nil, err
+		}
+	}
+	return config.Load(file)
+}
+
+//applyConfig sets flags from the configuration if they
+//were not set in the command line, so flags are always
+//overriding the configuration.
+func applyConfig(cfg *config.Config) error {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	values := make(map[string][]string)
+	addString := func(name string, value string) {
+		if len(value) > 0 {
+			values[name] = append(values[name], value)
+		}
+	}
+	addBool := func(name string, value *bool) {
+		if value != nil {
+			addString(name, strconv.FormatBool(*value))
+		}
+	}
+	addString("format", cfg.Format)
+	addString("kinds", strings.Join(cfg.Kinds, ","))
+	addString("out", cfg.FilePath(cfg.Out))
+	addString("patch", cfg.FilePath(cfg.Patch))
+	addString("naming", cfg.Naming)
+	addString("names", cfg.FilePath(cfg.Names))
+	addString("fallbackprefix", cfg.FallbackPrefix)
+	addString("fallbacksuffix", cfg.FallbackSuffix)
+	addBool("ignoretests", cfg.IgnoreTests)
+	addBool("dead", cfg.Dead)
+	addBool("includeapi", cfg.IncludeAPI)
+	addBool("transitive", cfg.Transitive)
+	addBool("unreachable", cfg.Unreachable)
+	addBool("apiroots", cfg.APIRoots)
+	addBool("reflected", cfg.Reflected)
+	addBool("strict", cfg.Strict)
+	addBool("rename", cfg.Rename)
+	addBool("remove", cfg.Remove)
+	addBool("verify", cfg.Verify)
+	for _, consumer := range cfg.Consumers {
+		addString("consumer", cfg.Package(consumer))
+	}
+	for _, root := range cfg.Roots {
+		addString("root", root)
+	}
+	for _, ctxt := range configContexts(cfg) {
+		addString("context", ctxt)
+	}
+
+	for name, flagValues := range values {
+		if set[name] {
+			util.Debug("flag -%s overrides configuration", name)
+			continue
+		}
+		for _, value := range flagValues {
+			if err := flag.Set(name, value); err != nil {
+				return fmt.Errorf("%s: invalid value of %s: %v", cfg.File, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+//configContexts returns contexts from the configuration with
+//build tags. If only tags are set, then they are added to
+//the current platform.
+func configContexts(cfg *config.Config) []string {
+	contexts := cfg.Contexts
+	if len(contexts) == 0 && len(cfg.Tags) > 0 {
+		contexts = []string{build.Default.GOOS + "/" + build.Default.GOARCH}
+	}
+
+	var result []string
+	for _, ctxt := range contexts {
+		result = append(result, strings.Join(append([]string{ctxt}, cfg.Tags...), ","))
+	}
+	return result
+}
+
+//configPackages returns packages from the configuration.
+//Relative directories are resolved from the directory
+//of the configuration file.
+func configPackages(cfg *config.Config) []string {
+	var result []string
+	for _, pkg := range cfg.Packages {
+		result = append(result, cfg.Package(pkg))
+	}
+	return result
+}
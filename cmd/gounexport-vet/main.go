@@ -0,0 +1,19 @@
+//Command gounexport-vet runs gounexport analyzer as a standalone
+//tool or as a vet tool. For example,
+//  gounexport-vet ./...
+//  go vet -vettool=$(which gounexport-vet) ./...
+//
+//Run gounexport-vet help for all flags of the analyzer.
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/dooman87/gounexport/analyzer"
+	"github.com/dooman87/gounexport/util"
+)
+
+func main() {
+	util.Level = "ERROR"
+	singlechecker.Main(analyzer.Analyzer)
+}
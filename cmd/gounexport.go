@@ -1,33 +1,27 @@
 //Package cmd provides command line interface to gounexport tool.
 //
-//Command requires package name. For, example:
+//Command requires package name or directory. For, example:
 //  gounexport github.com/dooman87/gounexport
+//  gounexport ./lib ./app/...
 //
-//There are next supported flags:
+//Several packages are analyzed together, so usages of definitions from
+//one package in another are counted. Inner packages are always analyzed.
+//Packages that are using analyzed ones could be passed with -consumer
+//flag, they are parsed only to collect usages.
 //
-//  -exclude string
-//    	File with exlude patterns for objects that shouldn't be unexported.Each pattern should be started at new line. Default pattern is Test* to exclude tests methods.
-//  -out string
-//    	Output file. If not set then stdout will be used
-//  -rename
-//    	If set, then all defenitions that will be determined as unused will be renamed in files
-//  -verbose
-//    	Turning on verbose mode
+//There are three modes:
+//  - by default, exported definitions that are not used outside of their
+//    package are reported and could be unexported with -rename flag;
+//  - -dead (or -transitive) reports definitions that are not used anywhere;
+//  - -unreachable reports definitions that are not reachable from roots.
+//Dead and unreachable definitions could be deleted with -remove flag. Use
+//-diff or -patch flags to review changes without changing files. Files are
+//type checked after changes and restored if there are new type errors.
 //
-//Exclude flag is pointing to file with regular expressions to ignore
-//public unexported symbols. Each expression should be starterd
-//with a new line. It's a standard go/regexp package. For example,
-//below we are excluding all Test methods and everything from package
-//public/api/pack/:
+//Report is printed as text, JSON or SARIF 2.1.0 (-format flag). Flags could
+//be stored in .gounexport.yaml or .gounexport.toml, see config package.
 //
-//  Test*
-//  public/api/packag/*
-//
-//Use -rename flag carefully and check output before.
-//
-//BUG(d): The tool is not analyzing test files if package in the test file is not
-//the same as a base package. For instance, pack/pack_test.go is in package pack_test
-//instead of pack
+//See README.md for the reference of flags, configuration and JSON schema.
 package main
 
 import (
@@ -35,18 +29,30 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
 
-	"go/ast"
+	"go/build"
 	"go/types"
 
 	"github.com/dooman87/gounexport"
+	"github.com/dooman87/gounexport/config"
+	"github.com/dooman87/gounexport/edit"
 	"github.com/dooman87/gounexport/fs"
 	"github.com/dooman87/gounexport/util"
 )
 
+//reportMode is a kind of reported definitions
+type reportMode string
+
+const (
+	modeUnused      reportMode = "unused"
+	modeDead        reportMode = "dead"
+	modeUnreachable reportMode = "unreachable"
+)
+
 type sortableDefinition struct {
 	defs []*gounexport.Definition
 }
@@ -58,6 +64,9 @@ func (sortDefs *sortableDefinition) Len() int {
 func (sortDefs *sortableDefinition) Less(i int, j int) bool {
 	iDef := sortDefs.defs[i]
 	jDef := sortDefs.defs[j]
+	if iPkg, jPkg := definitionPackage(iDef), definitionPackage(jDef); iPkg != jPkg {
+		return iPkg < jPkg
+	}
 	if iDef.File != jDef.File {
 		return iDef.File < jDef.File
 	}
@@ -73,20 +82,144 @@ func (sortDefs *sortableDefinition) Swap(i, j int) {
 	sortDefs.defs[j] = temp
 }
 
+//contextsFlag is a flag that could be repeated
+//to collect several build contexts.
+type contextsFlag []*build.Context
+
+func (contexts *contextsFlag) String() string {
+	var result []string
+	for _, ctxt := range *contexts {
+		result = append(result, strings.Join(append([]string{ctxt.GOOS + "/" + ctxt.GOARCH}, ctxt.BuildTags...), ","))
+	}
+	return strings.Join(result, " ")
+}
+
+func (contexts *contextsFlag) Set(value string) error {
+	ctxt, err := parseBuildContext(value)
+	if err != nil {
+		return err
+	}
+	*contexts = append(*contexts, ctxt)
+	return nil
+}
+
+//stringsFlag is a flag that could be repeated
+//to collect several values.
+type stringsFlag []string
+
+func (values *stringsFlag) String() string {
+	return strings.Join(*values, " ")
+}
+
+func (values *stringsFlag) Set(value string) error {
+	*values = append(*values, value)
+	return nil
+}
+
+//command is a parsed command line with options of the analysis
+type command struct {
+	pkgs      []string
+	consumers []string
+	contexts  []*build.Context
+	opts      *gounexport.Options
+	naming    *gounexport.Naming
+	strict    bool
+	out       string
+	format    string
+	diff      bool
+	patch     string
+	rename    bool
+	remove    bool
+	verify    bool
+}
+
+//finder returns reported definitions of the packages
+type finder func(pkgs []string, defs map[string]*gounexport.Definition, opts *gounexport.Options) []*gounexport.Definition
+
 func main() {
+	cmd, mode := parseCommand()
+	if len(cmd.pkgs) == 0 {
+		fmt.Printf("Usage: gounexport [OPTIONS] package...\n")
+		flag.PrintDefaults()
+		return
+	}
+
+	var err error
+	switch mode {
+	case modeDead:
+		err = runDead(cmd)
+	case modeUnreachable:
+		err = runUnreachable(cmd)
+	default:
+		err = runUnused(cmd)
+	}
+	if err != nil {
+		util.Fatalf("%v", err)
+	}
+}
+
+//parseCommand parses flags and applies the configuration.
+//Returns the command and the mode of the report.
+func parseCommand() (*command, reportMode) {
 	var err error
+	var contexts contextsFlag
+	var consumers stringsFlag
+	var roots stringsFlag
 
 	rename := flag.Bool("rename", false,
 		"If set, then all defenitions "+
 			"that will be determined as unused will be renamed in files")
+	namingStrategy := flag.String("naming", string(gounexport.NamingInitialisms),
+		"Naming strategy for new names: "+namingStrategyNames()+". initialisms lowercases leading initialism (HTTPClient -> httpClient), first lowercases only the first letter")
+	names := flag.String("names", "",
+		"File with new names. Each line contains full or simple name of definition and the new name separated by space")
+	fallbackPrefix := flag.String("fallbackprefix", "",
+		"Prefix that is added to the original name if the new name conflicts with other identifier")
+	fallbackSuffix := flag.String("fallbacksuffix", "",
+		"Suffix that is added to the new name if it conflicts with other identifier")
+	verify := flag.Bool("verify", true,
+		"If set, then package is type checked after renaming or removing and files are restored if there are new errors")
+	remove := flag.Bool("remove", false,
+		"If set, then dead or unreachable definitions will be removed from files. Requires -dead or -unreachable")
+	diff := flag.Bool("diff", false,
+		"If set, then unified diff of renaming (or removing) will be printed to stdout. Files are not changed")
+	patch := flag.String("patch", "",
+		"File to write unified diff of renaming (or removing). Files are not changed")
 	verbose := flag.Bool("verbose", false, "Turning on verbose mode")
+	strict := flag.Bool("strict", false,
+		"If set, then nothing is reported if package has type errors")
+	dead := flag.Bool("dead", false,
+		"If set, then definitions that are not used anywhere, including unexported ones, are reported instead of unused exported definitions")
+	transitive := flag.Bool("transitive", false,
+		"If set, then definitions that are used only by dead code are dead too. Turns on -dead")
+	unreachable := flag.Bool("unreachable", false,
+		"If set, then definitions that are not reachable from main, init, test functions and other roots are reported instead of unused exported definitions")
+	flag.Var(&roots, "root",
+		"Regular expression for full names of definitions that are roots of -unreachable analysis. Could be repeated")
+	includeAPI := flag.Bool("includeapi", false,
+		"If set, then exported definitions of packages that are not main are reported by -dead analysis. They are API of the library by default")
+	apiRoots := flag.Bool("apiroots", false,
+		"If set, then exported definitions of packages that are not main are roots of -unreachable analysis")
+	reflected := flag.Bool("reflected", false,
+		"If set, then definitions that are reachable through reflection (struct tags, encoders, templates) are reported as well")
+	ignoreTests := flag.Bool("ignoretests", false,
+		"If set, then usages from external test packages (package foo_test) are not counted")
 	out := flag.String("out", "", "Output file. If not set then stdout will be used")
+	format := flag.String("format", "text", "Output format: text, json or sarif")
+	flag.Var(&contexts, "context",
+		"Build context in format GOOS/GOARCH[,tag...]. Could be repeated to analyze several contexts")
+	flag.Var(&consumers, "consumer",
+		"Package or directory that is using analyzed packages. It's parsed only to collect usages. Could be repeated")
+	kinds := flag.String("kinds", "",
+		"Comma separated kinds of definitions to report: "+kindNames()+". All kinds are reported by default")
 	exclude := flag.String("exclude", "",
 		"File with exlude patterns for objects that shouldn't be unexported."+
 			"Each pattern should be started at new line. Default pattern is Test* to exclude tests methods.")
+	configFile := flag.String("config", "",
+		"Configuration file. By default, "+strings.Join(config.FileNames, ", ")+
+			" is searched from the working directory up to the root")
 
 	flag.Parse()
-	pkg := flag.Arg(0)
 
 	//Setup logging
 	if *verbose {
@@ -95,32 +228,223 @@ func main() {
 		util.Level = "ERROR"
 	}
 
+	//Flags are overriding configuration
+	args := flag.Args()
+	cfg, err := loadConfig(*configFile)
+	if err != nil {
+		util.Fatalf("error while loading configuration: %v", err)
+	}
+	if cfg != nil {
+		if err := applyConfig(cfg); err != nil {
+			util.Fatalf("error while applying configuration: %v", err)
+		}
+		if len(args) == 0 {
+			args = configPackages(cfg)
+		}
+	}
+
+	cmd := new(command)
+	cmd.pkgs = resolvePackages(args)
+	cmd.consumers = resolveConsumers(consumers, cmd.pkgs)
+	cmd.contexts = contexts
+	cmd.strict = *strict
+	cmd.out = *out
+	cmd.format = *format
+	cmd.diff = *diff
+	cmd.patch = *patch
+	cmd.rename = *rename
+	cmd.remove = *remove
+	cmd.verify = *verify
+
+	mode := modeUnused
+	if *dead || *transitive {
+		mode = modeDead
+	}
+	if *unreachable {
+		if mode == modeDead {
+			util.Fatalf("-unreachable can't be used with -dead")
+		}
+		mode = modeUnreachable
+	}
+
 	//Setup excludes
+	opts := new(gounexport.Options)
+	opts.IgnoreTestUsages = *ignoreTests
+	opts.IncludeReflected = *reflected
+	opts.Transitive = *transitive
+	opts.APIRoots = *apiRoots
+	opts.IncludeAPI = *includeAPI
+	opts.Roots, err = compilePatterns(roots)
+	if err != nil {
+		util.Fatalf("error while compiling roots: %v", err)
+	}
 	defaultRegexp, _ := regexp.Compile("Test*")
-	excludeRegexps := []*regexp.Regexp{defaultRegexp}
+	opts.Excludes = []*regexp.Regexp{defaultRegexp}
 	if len(*exclude) > 0 {
-		excludeRegexps, err = readExcludes(*exclude)
+		opts.Excludes, err = readExcludes(*exclude)
+		if err != nil {
+			util.Fatalf("error while reading excludes: %v", err)
+		}
+	} else if cfg != nil && len(cfg.Excludes) > 0 {
+		opts.Excludes, err = compilePatterns(cfg.Excludes)
+		if err != nil {
+			util.Fatalf("error while compiling excludes from configuration: %v", err)
+		}
+	}
+	if len(*kinds) > 0 {
+		opts.Kinds, err = gounexport.ParseKinds(strings.Split(*kinds, ","))
 		if err != nil {
-			util.Fatalf("error while setup logging: %v", err)
+			util.Fatalf("error while parsing kinds: %v", err)
 		}
 	}
+	cmd.opts = opts
 
-	//Looking up for unused definitions, print them and rename
-	if len(pkg) > 0 {
-		unusedDefinitions, allDefinitions, err := getUnusedDefinitions(pkg, excludeRegexps)
+	naming := gounexport.NewNaming()
+	naming.Strategy, err = gounexport.ParseNamingStrategy(*namingStrategy)
+	if err != nil {
+		util.Fatalf("error while parsing naming strategy: %v", err)
+	}
+	if len(*names) > 0 {
+		naming.Mapping, err = gounexport.ReadNamingMapping(*names)
 		if err != nil {
-			util.Fatalf("error while getting definitions: %v", err)
+			util.Fatalf("error while reading names: %v", err)
 		}
-		if err := printDefinitions(*out, unusedDefinitions); err != nil {
-			util.Fatalf("error while printing result: %v", err)
+	}
+	naming.FallbackPrefix = *fallbackPrefix
+	naming.FallbackSuffix = *fallbackSuffix
+	cmd.naming = naming
+
+	return cmd, mode
+}
+
+//runUnused reports exported definitions that are not used
+//outside of their packages and renames them with -rename flag.
+func runUnused(cmd *command) error {
+	if cmd.remove {
+		return fmt.Errorf("-remove requires -dead, -transitive or -unreachable")
+	}
+	return cmd.run(modeUnused, gounexport.FindUnusedDefinitionsInPackages, cmd.rename, "renaming")
+}
+
+//runDead reports definitions that are not used anywhere
+//and removes them with -remove flag.
+func runDead(cmd *command) error {
+	if cmd.rename {
+		return fmt.Errorf("-rename is not supported with -%s, use -remove", modeDead)
+	}
+	return cmd.run(modeDead, gounexport.FindDeadDefinitions, cmd.remove, "removing")
+}
+
+//runUnreachable reports definitions that are not reachable
+//from roots and removes them with -remove flag.
+func runUnreachable(cmd *command) error {
+	if cmd.rename {
+		return fmt.Errorf("-rename is not supported with -%s, use -remove", modeUnreachable)
+	}
+	return cmd.run(modeUnreachable, gounexport.FindUnreachableDefinitions, cmd.remove, "removing")
+}
+
+//run prints definitions that are found by find function. Then edits
+//are printed as diff with -diff or -patch flags or written to files
+//if change is true.
+func (cmd *command) run(mode reportMode, find finder, change bool, operation string) error {
+	unused, allDefs, err := getUnusedDefinitions(cmd.pkgs, cmd.consumers, cmd.contexts, cmd.opts, cmd.strict, find)
+	if err != nil {
+		return fmt.Errorf("error while getting definitions: %v", err)
+	}
+	if err := printDefinitions(cmd.out, cmd.format, unused, allDefs, mode, cmd.naming); err != nil {
+		return fmt.Errorf("error while printing result: %v", err)
+	}
+	if cmd.diff || len(cmd.patch) > 0 {
+		if err := diffDefinitions(cmd.patch, collectEdits(mode, unused, allDefs, cmd.naming)); err != nil {
+			return fmt.Errorf("error while creating diff: %v", err)
 		}
-		if *rename {
-			renameDefinitions(unusedDefinitions, allDefinitions)
+	} else if change {
+		set := collectEdits(mode, unused, allDefs, cmd.naming)
+		if err := commitEdits(append(cmd.pkgs, cmd.consumers...), cmd.contexts, cmd.verify, set, operation); err != nil {
+			return fmt.Errorf("error while changing files, files were not changed: %v", err)
 		}
-	} else {
-		fmt.Printf("Usage: gounexport [OPTIONS] package\n")
-		flag.PrintDefaults()
 	}
+	return nil
+}
+
+func namingStrategyNames() string {
+	var names []string
+	for _, strategy := range gounexport.AllNamingStrategies {
+		names = append(names, string(strategy))
+	}
+	return strings.Join(names, ", ")
+}
+
+func kindNames() string {
+	var names []string
+	for _, kind := range gounexport.AllKinds {
+		names = append(names, string(kind))
+	}
+	return strings.Join(names, ", ")
+}
+
+//resolvePackages converts arguments to package paths. Inner packages
+//are always analyzed, so /... suffix is trimmed and packages that
+//are inside of other ones are skipped.
+func resolvePackages(args []string) []string {
+	var pkgs []string
+	for _, arg := range args {
+		pkg := resolvePackage(strings.TrimSuffix(strings.TrimSuffix(arg, "..."), "/"))
+		if len(pkg) > 0 {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	sort.Strings(pkgs)
+
+	var result []string
+	for _, pkg := range pkgs {
+		if len(result) > 0 && isInnerPackage(pkg, result[len(result)-1]) {
+			util.Debug("skipping [%s], because it's inside of [%s]", pkg, result[len(result)-1])
+			continue
+		}
+		result = append(result, pkg)
+	}
+	return result
+}
+
+//resolveConsumers converts consumers to package paths. Consumers
+//that are inside of analyzed packages are skipped, because they
+//are parsed anyway.
+func resolveConsumers(consumers []string, pkgs []string) []string {
+	var result []string
+	for _, consumer := range resolvePackages(consumers) {
+		inner := false
+		for _, pkg := range pkgs {
+			inner = inner || isInnerPackage(consumer, pkg)
+		}
+		if inner {
+			util.Debug("skipping consumer [%s], because it's analyzed", consumer)
+			continue
+		}
+		result = append(result, consumer)
+	}
+	return result
+}
+
+func isInnerPackage(pkg string, parent string) bool {
+	return pkg == parent || strings.HasPrefix(pkg, parent+"/")
+}
+
+//resolvePackage converts directory to the package path.
+//Returns pkg as is if it's not a directory.
+func resolvePackage(pkg string) string {
+	if len(pkg) == 0 {
+		pkg = "."
+	}
+	if !strings.HasPrefix(pkg, ".") && !filepath.IsAbs(pkg) {
+		return pkg
+	}
+	dir, err := filepath.Abs(pkg)
+	if err != nil {
+		util.Fatalf("error while resolving directory %s: %v", pkg, err)
+	}
+	return fs.GetPackagePath(dir)
 }
 
 func readExcludes(file string) ([]*regexp.Regexp, error) {
@@ -128,46 +452,176 @@ func readExcludes(file string) ([]*regexp.Regexp, error) {
 	if err != nil {
 		return nil, err
 	}
+	return compilePatterns(strings.Split(string(bytes), "\n"))
+}
 
+//compilePatterns compiles regular expressions skipping empty ones
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
 	var result []*regexp.Regexp
-	regexpStrings := strings.Split(string(bytes), "\n")
-	for _, regexpS := range regexpStrings {
-		if len(regexpS) == 0 {
+	for _, pattern := range patterns {
+		if len(pattern) == 0 {
 			continue
 		}
-		var regex *regexp.Regexp
-		regex, err = regexp.Compile(regexpS)
+		regex, err := regexp.Compile(pattern)
 		if err != nil {
 			return nil, err
 		}
 		result = append(result, regex)
 	}
-	return result, err
+	return result, nil
 }
 
-func renameDefinitions(unused []*gounexport.Definition, allDefs map[string]*gounexport.Definition) {
-	for _, def := range unused {
-		gounexport.Unexport(def, allDefs, fs.ReplaceStringInFile)
+//commitEdits writes all changed files at once. If any file can't be
+//written, then all files are restored. If verify is true, then files
+//are restored as well when changes cause new type errors in pkgs.
+//operation is used in messages, e.g. renaming or removing.
+func commitEdits(pkgs []string, contexts []*build.Context, verify bool, set *edit.Set, operation string) error {
+	var before []types.Error
+	var err error
+	if verify {
+		if before, err = gounexport.TypeCheck(pkgs, contexts); err != nil {
+			return err
+		}
+	}
+
+	if err := set.Commit(); err != nil {
+		return err
+	}
+
+	if verify {
+		newErrors, err := gounexport.Verify(pkgs, contexts, before)
+		if err == nil && len(newErrors) > 0 {
+			for _, e := range newErrors {
+				fmt.Fprintf(os.Stderr, "%v\n", e)
+			}
+			err = fmt.Errorf("%s caused %d new type errors", operation, len(newErrors))
+		}
+		if err != nil {
+			if rollbackErr := set.Rollback(); rollbackErr != nil {
+				return fmt.Errorf("%v, restore failed: %v", err, rollbackErr)
+			}
+			return err
+		}
 	}
+	return nil
 }
 
-func getUnusedDefinitions(pkg string, excludes []*regexp.Regexp) (
-	[]*gounexport.Definition, map[string]*gounexport.Definition, error) {
-	info := types.Info{
-		Types: make(map[ast.Expr]types.TypeAndValue),
-		Defs:  make(map[*ast.Ident]types.Object),
-		Uses:  make(map[*ast.Ident]types.Object),
+//collectEdits collects renames of all definitions that could be unexported
+//to the edit set. In dead and unreachable modes, definitions are removed
+//and files are tidied after that.
+func collectEdits(mode reportMode, unused []*gounexport.Definition,
+	allDefs map[string]*gounexport.Definition, naming *gounexport.Naming) *edit.Set {
+	set := edit.NewSet()
+	if mode != modeUnused {
+		set.AddFormatter(gounexport.TidyFile)
+	}
+	if mode != modeUnused {
+		for def, err := range gounexport.RemoveDefinitions(unused, set.Add) {
+			util.Warn("skipping [%s]: %v", def.Name, err)
+		}
+		return set
 	}
-	_, fset, err := gounexport.ParsePackage(pkg, &info)
+	for _, def := range unused {
+		if err := gounexport.UnexportWithNaming(def, allDefs, naming, set.Add); err != nil {
+			util.Warn("skipping [%s]: %v", def.Name, err)
+		}
+	}
+	return set
+}
+
+//diffDefinitions prints unified diff of edits to the
+//file or to stdout if filename is empty.
+func diffDefinitions(filename string, set *edit.Set) error {
+	diff, err := set.Diff()
 	if err != nil {
+		return err
+	}
+	if len(filename) > 0 {
+		return ioutil.WriteFile(filename, []byte(diff), 0644)
+	}
+	fmt.Print(diff)
+	return nil
+}
+
+//getUnusedDefinitions returns unused definitions of the packages. Packages
+//and consumers are parsed together, so usages between them are counted,
+//but definitions of consumers are not reported. If packages
+//have type errors, then they are printed with a summary to stderr. In strict
+//mode type errors are returned, otherwise analysis is continued, but result
+//could contain definitions that are actually used. Reported definitions
+//are found by find function, so dead or unreachable ones could be returned instead.
+func getUnusedDefinitions(pkgs []string, consumers []string, contexts []*build.Context,
+	opts *gounexport.Options, strict bool, find finder) ([]*gounexport.Definition, map[string]*gounexport.Definition, error) {
+	defs, err := gounexport.GetPackagesDefinitions(append(pkgs, consumers...), contexts)
+	if typeErrors, ok := err.(gounexport.TypeErrors); ok {
+		printTypeErrors(typeErrors)
+		if strict {
+			return nil, nil, fmt.Errorf("package has type errors")
+		}
+	} else if err != nil {
 		return nil, nil, err
 	}
-	defs := gounexport.GetDefinitions(&info, fset)
-	return gounexport.FindUnusedDefinitions(pkg, defs, excludes), defs, nil
+	opts.Directives = gounexport.NewDirectives()
+	unused := find(pkgs, defs, opts)
+	printStaleDirectives(opts.Directives.Stale())
+	return unused, defs, nil
+}
+
+//printStaleDirectives prints keep directives that are not
+//suppressing anything, so they could be removed.
+func printStaleDirectives(stale []*gounexport.Directive) {
+	for _, d := range stale {
+		fmt.Fprintf(os.Stderr, "%v: stale %s directive, it doesn't suppress any unused definition\n",
+			d.Pos, gounexport.KeepDirective)
+	}
+}
+
+func printTypeErrors(typeErrors gounexport.TypeErrors) {
+	for _, e := range typeErrors {
+		fmt.Fprintf(os.Stderr, "%v\n", e)
+	}
+	fmt.Fprintf(os.Stderr, "%d type errors in %d files of %d packages: %v\n",
+		len(typeErrors), len(typeErrors.Files()), len(typeErrors.Packages()), typeErrors.Packages())
+}
+
+//parseBuildContext creates build context from string
+//in format GOOS/GOARCH[,tag...]
+func parseBuildContext(value string) (*build.Context, error) {
+	parts := strings.Split(value, ",")
+	platform := strings.Split(parts[0], "/")
+	if len(platform) != 2 || len(platform[0]) == 0 || len(platform[1]) == 0 {
+		return nil, fmt.Errorf("invalid build context [%s], expected GOOS/GOARCH[,tag...]", value)
+	}
+
+	ctxt := build.Default
+	ctxt.GOOS = platform[0]
+	ctxt.GOARCH = platform[1]
+	ctxt.BuildTags = parts[1:]
+	return &ctxt, nil
 }
 
-func printDefinitions(filename string, defs []*gounexport.Definition) error {
-	output := definitionsToString(defs)
+func printDefinitions(filename string, format string, defs []*gounexport.Definition,
+	allDefs map[string]*gounexport.Definition, mode reportMode, naming *gounexport.Naming) error {
+	sDef := new(sortableDefinition)
+	sDef.defs = defs
+	sort.Sort(sDef)
+
+	var output string
+	var err error
+	switch format {
+	case "text":
+		output = definitionsToString(defs, mode)
+	case "json":
+		output, err = definitionsToJSON(defs, mode)
+	case "sarif":
+		output, err = definitionsToSARIF(defs, allDefs, mode, naming)
+	default:
+		err = fmt.Errorf("unknown format [%s]", format)
+	}
+	if err != nil {
+		return err
+	}
+
 	if len(filename) > 0 {
 		if err := ioutil.WriteFile(filename, []byte(output), os.ModePerm); err != nil {
 			return err
@@ -178,18 +632,41 @@ func printDefinitions(filename string, defs []*gounexport.Definition) error {
 	return nil
 }
 
-func definitionsToString(defs []*gounexport.Definition) string {
-	sDef := new(sortableDefinition)
-	sDef.defs = defs
-	sort.Sort(sDef)
+//definitionsToString prints definitions grouped by package.
+//Definitions should be sorted before.
+func definitionsToString(defs []*gounexport.Definition, mode reportMode) string {
+	packages := make(map[string]int)
+	for _, def := range defs {
+		packages[definitionPackage(def)]++
+	}
 
 	result := "-----------------------------------------------------\n"
-	result += fmt.Sprintf("Found %d unused definitions\n", len(defs))
+	result += fmt.Sprintf("Found %d %s definitions in %d packages\n", len(defs), mode, len(packages))
+	pkg := ""
 	for _, def := range defs {
-		result += fmt.Sprintf("%s - %s:%d:%d\n", def.Name, def.File, def.Line, def.Col)
+		if defPkg := definitionPackage(def); defPkg != pkg || len(pkg) == 0 {
+			pkg = defPkg
+			result += fmt.Sprintf("\n%s (%d):\n", pkg, packages[pkg])
+		}
+		result += fmt.Sprintf("%s - %s:%d:%d", def.Name, def.File, def.Line, def.Col)
+		if len(def.Reflection) > 0 {
+			result += fmt.Sprintf(" (reflection: %s)", def.Reflection)
+		}
+		result += "\n"
 		for _, u := range def.Usages {
-			result += fmt.Sprintf("\t%s:%d:%d\n", u.Pos.Filename, u.Pos.Line, u.Pos.Column)
+			result += fmt.Sprintf("\t%s:%d:%d", u.Pos.Filename, u.Pos.Line, u.Pos.Column)
+			if len(u.Evidence) > 0 {
+				result += fmt.Sprintf(" (dynamic: %s)", u.Evidence)
+			}
+			result += "\n"
 		}
 	}
 	return result
 }
+
+func definitionPackage(def *gounexport.Definition) string {
+	if def.Pkg == nil {
+		return ""
+	}
+	return def.Pkg.Path()
+}
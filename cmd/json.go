@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/dooman87/gounexport"
+)
+
+//jsonSchemaVersion should be increased on each incompatible
+//change of the JSON report
+const jsonSchemaVersion = 3
+
+//jsonReport is a root of JSON report.
+type jsonReport struct {
+	Version     int               `json:"version"`
+	Mode        string            `json:"mode"`
+	Summary     *jsonSummary      `json:"summary"`
+	Definitions []*jsonDefinition `json:"definitions"`
+}
+
+type jsonSummary struct {
+	//Total number of reported definitions, they are unused,
+	//dead or unreachable depending on the mode of the report
+	Reported int `json:"reported"`
+	//Number of packages and files with reported definitions
+	Packages int `json:"packages"`
+	Files    int `json:"files"`
+	//Number of reported definitions by kind
+	Kinds map[string]int `json:"kinds"`
+	//Number of reported definitions by package
+	ByPackage map[string]int `json:"byPackage"`
+}
+
+type jsonDefinition struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	SimpleName string       `json:"simpleName"`
+	Kind       string       `json:"kind"`
+	Package    string       `json:"package"`
+	File       string       `json:"file"`
+	Line       int          `json:"line"`
+	Col        int          `json:"col"`
+	Offset     int          `json:"offset"`
+	Interfaces []string     `json:"interfaces"`
+	Usages     []*jsonUsage `json:"usages"`
+	Reflection string       `json:"reflection,omitempty"`
+}
+
+type jsonUsage struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Col      int    `json:"col"`
+	Offset   int    `json:"offset"`
+	Package  string `json:"package"`
+	Evidence string `json:"evidence,omitempty"`
+}
+
+//definitionsToJSON serializes definitions to JSON report.
+//Definitions should be sorted before.
+func definitionsToJSON(defs []*gounexport.Definition, mode reportMode) (string, error) {
+	report := new(jsonReport)
+	report.Version = jsonSchemaVersion
+	report.Mode = string(mode)
+	report.Summary = new(jsonSummary)
+	report.Summary.Kinds = make(map[string]int)
+	report.Summary.ByPackage = make(map[string]int)
+	report.Definitions = make([]*jsonDefinition, 0, len(defs))
+
+	files := make(map[string]bool)
+	for _, def := range defs {
+		jsonDef := newJSONDefinition(def)
+		report.Definitions = append(report.Definitions, jsonDef)
+		report.Summary.Kinds[jsonDef.Kind]++
+		report.Summary.ByPackage[jsonDef.Package]++
+		files[jsonDef.File] = true
+	}
+	report.Summary.Reported = len(defs)
+	report.Summary.Packages = len(report.Summary.ByPackage)
+	report.Summary.Files = len(files)
+
+	result, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(result) + "\n", nil
+}
+
+func newJSONDefinition(def *gounexport.Definition) *jsonDefinition {
+	jsonDef := new(jsonDefinition)
+	jsonDef.ID = def.ID
+	jsonDef.Name = def.Name
+	jsonDef.SimpleName = def.SimpleName
+	jsonDef.Kind = string(def.Kind)
+	jsonDef.Reflection = def.Reflection
+	if def.Pkg != nil {
+		jsonDef.Package = def.Pkg.Path()
+	}
+	jsonDef.File = def.File
+	jsonDef.Line = def.Line
+	jsonDef.Col = def.Col
+	jsonDef.Offset = def.Offset
+
+	jsonDef.Interfaces = make([]string, 0, len(def.Interfaces))
+	for _, i := range def.Interfaces {
+		jsonDef.Interfaces = append(jsonDef.Interfaces, i.Name)
+	}
+	sort.Strings(jsonDef.Interfaces)
+
+	jsonDef.Usages = make([]*jsonUsage, 0, len(def.Usages))
+	for _, u := range def.Usages {
+		jsonDef.Usages = append(jsonDef.Usages, &jsonUsage{
+			File:     u.Pos.Filename,
+			Line:     u.Pos.Line,
+			Col:      u.Pos.Column,
+			Offset:   u.Pos.Offset,
+			Package:  u.Pkg,
+			Evidence: u.Evidence,
+		})
+	}
+	sort.Slice(jsonDef.Usages, func(i, j int) bool {
+		if jsonDef.Usages[i].File != jsonDef.Usages[j].File {
+			return jsonDef.Usages[i].File < jsonDef.Usages[j].File
+		}
+		return jsonDef.Usages[i].Offset < jsonDef.Usages[j].Offset
+	})
+	return jsonDef
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dooman87/gounexport"
+)
+
+var update = flag.Bool("update", false, "If set, then golden files are updated")
+
+const reportPackage = "github.com/dooman87/gounexport/cmd/testdata/testreport"
+
+func TestPrintDefinitionsGolden(t *testing.T) {
+	for _, format := range []string{"json", "sarif"} {
+		assertReport(t, modeUnused, gounexport.FindUnusedDefinitionsInPackages, format,
+			filepath.Join("testdata", "report."+format+".golden"))
+	}
+}
+
+func TestPrintDeadDefinitionsGolden(t *testing.T) {
+	assertReport(t, modeDead, gounexport.FindDeadDefinitions, "json",
+		filepath.Join("testdata", "report_dead.json.golden"))
+}
+
+//assertReport prints definitions of the report package
+//that are found by find function and compares it with
+//the golden file.
+func assertReport(t *testing.T, mode reportMode, find finder, format string, golden string) {
+	opts := new(gounexport.Options)
+	unused, allDefs, err := getUnusedDefinitions([]string{reportPackage}, nil, nil, opts, true, find)
+	if err != nil {
+		t.Fatalf("error while getting definitions: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatalf("can't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	srcDir, err := filepath.Abs(filepath.Join("testdata", "testreport"))
+	if err != nil {
+		t.Fatalf("can't resolve testdata: %v", err)
+	}
+
+	out := filepath.Join(dir, "report."+format)
+	if err := printDefinitions(out, format, unused, allDefs, mode, gounexport.NewNaming()); err != nil {
+		t.Fatalf("error while printing %s: %v", format, err)
+	}
+	assertGolden(t, out, golden, srcDir)
+}
+
+//assertGolden compares the file with the golden one. Source
+//directory is replaced with $DIR, so golden files don't
+//depend on the location of the repository.
+func assertGolden(t *testing.T, file string, golden string, srcDir string) {
+	bytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatalf("can't read %s: %v", file, err)
+	}
+	actual := strings.Replace(string(bytes), filepath.ToSlash(srcDir), "$DIR", -1)
+	if *update {
+		if err := ioutil.WriteFile(golden, []byte(actual), 0644); err != nil {
+			t.Fatalf("can't update %s: %v", golden, err)
+		}
+	}
+
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("can't read %s: %v", golden, err)
+	}
+	if actual != string(expected) {
+		t.Errorf("%s is different from %s, run go test -update to update it:\n%s", file, golden, actual)
+	}
+}
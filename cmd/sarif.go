@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/dooman87/gounexport"
+	"github.com/dooman87/gounexport/util"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	//sarifRuleID is a rule of unused exported definitions
+	sarifRuleID = "unused-export"
+	//sarifDeadRuleID is a rule of definitions that are not used anywhere
+	sarifDeadRuleID = "dead-code"
+	//sarifUnreachableRuleID is a rule of definitions that are not reachable from roots
+	sarifUnreachableRuleID = "unreachable-code"
+)
+
+//sarifLog is a root object of SARIF 2.1.0 report.
+//Only properties that are used by gounexport are declared.
+type sarifLog struct {
+	Schema  string      `json:"$schema"`
+	Version string      `json:"version"`
+	Runs    []*sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       *sarifTool     `json:"tool"`
+	ColumnKind string         `json:"columnKind"`
+	Results    []*sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver *sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string       `json:"name"`
+	InformationURI string       `json:"informationUri"`
+	Rules          []*sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string              `json:"id"`
+	Name                 string              `json:"name"`
+	ShortDescription     *sarifMessage       `json:"shortDescription"`
+	FullDescription      *sarifMessage       `json:"fullDescription"`
+	DefaultConfiguration *sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string           `json:"ruleId"`
+	RuleIndex int              `json:"ruleIndex"`
+	Level     string           `json:"level"`
+	Message   *sarifMessage    `json:"message"`
+	Locations []*sarifLocation `json:"locations"`
+	Fixes     []*sarifFix      `json:"fixes,omitempty"`
+	//PartialFingerprints are used to match results between runs
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation *sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+	ByteOffset  int `json:"byteOffset"`
+	ByteLength  int `json:"byteLength"`
+}
+
+type sarifFix struct {
+	Description     *sarifMessage          `json:"description"`
+	ArtifactChanges []*sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation *sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []*sarifReplacement    `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   *sarifRegion  `json:"deletedRegion"`
+	InsertedContent *sarifMessage `json:"insertedContent"`
+}
+
+//definitionsToSARIF converts definitions to SARIF report. Each definition is
+//a result with a fix that contains all replacements that Unexport would make.
+//In dead and unreachable modes definitions are results of dead-code and
+//unreachable-code rules without fixes. Definitions should be sorted before.
+func definitionsToSARIF(defs []*gounexport.Definition, allDefs map[string]*gounexport.Definition,
+	mode reportMode, naming *gounexport.Naming) (string, error) {
+	rule := &sarifRule{
+		ID:                   sarifRuleID,
+		Name:                 "UnusedExport",
+		ShortDescription:     &sarifMessage{Text: "Exported definition is not used outside of its package"},
+		FullDescription:      &sarifMessage{Text: "Exported definition is not used outside of its package and could be unexported by renaming to lower case."},
+		DefaultConfiguration: &sarifConfiguration{Level: "warning"},
+	}
+	switch mode {
+	case modeDead:
+		rule = &sarifRule{
+			ID:                   sarifDeadRuleID,
+			Name:                 "DeadCode",
+			ShortDescription:     &sarifMessage{Text: "Definition is not used anywhere"},
+			FullDescription:      &sarifMessage{Text: "Definition is not used anywhere or it's used only by other dead code, so it could be deleted."},
+			DefaultConfiguration: &sarifConfiguration{Level: "warning"},
+		}
+	case modeUnreachable:
+		rule = &sarifRule{
+			ID:                   sarifUnreachableRuleID,
+			Name:                 "UnreachableCode",
+			ShortDescription:     &sarifMessage{Text: "Definition is not reachable from roots"},
+			FullDescription:      &sarifMessage{Text: "Definition is not reachable from main, init, test functions and other roots, so it could be deleted."},
+			DefaultConfiguration: &sarifConfiguration{Level: "warning"},
+		}
+	}
+	run := new(sarifRun)
+	run.Tool = &sarifTool{
+		Driver: &sarifDriver{
+			Name:           "gounexport",
+			InformationURI: "https://github.com/dooman87/gounexport",
+			Rules:          []*sarifRule{rule},
+		},
+	}
+	//go/token reports columns in bytes, so they are
+	//converted to code points by reading source lines
+	run.ColumnKind = "unicodeCodePoints"
+	run.Results = make([]*sarifResult, 0, len(defs))
+	sources := make(map[string][]byte)
+	for _, def := range defs {
+		var result *sarifResult
+		switch mode {
+		case modeDead:
+			result = newSARIFDeadResult(def, sarifDeadRuleID, "is not used anywhere")
+		case modeUnreachable:
+			result = newSARIFDeadResult(def, sarifUnreachableRuleID, "is not reachable from roots")
+		default:
+			result = newSARIFResult(def, allDefs, naming)
+		}
+		setCodePointColumns(result.Locations[0].PhysicalLocation.Region, def, sources)
+		run.Results = append(run.Results, result)
+	}
+
+	log := &sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []*sarifRun{run},
+	}
+	result, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(result) + "\n", nil
+}
+
+func newSARIFResult(def *gounexport.Definition, allDefs map[string]*gounexport.Definition, naming *gounexport.Naming) *sarifResult {
+	result := newSARIFDefinitionResult(def, sarifRuleID)
+	result.Message = &sarifMessage{
+		Text: fmt.Sprintf("%s is exported, but not used outside of its package", def.Name),
+	}
+
+	if fix, err := newSARIFFix(def, allDefs, naming); err != nil {
+		util.Warn("can't create fix for [%s]: %v", def.Name, err)
+		result.Message.Text += fmt.Sprintf(", but can't be unexported: %v", err)
+	} else {
+		result.Fixes = []*sarifFix{fix}
+	}
+	return result
+}
+
+func newSARIFDeadResult(def *gounexport.Definition, ruleID string, message string) *sarifResult {
+	result := newSARIFDefinitionResult(def, ruleID)
+	result.Message = &sarifMessage{
+		Text: fmt.Sprintf("%s %s", def.Name, message),
+	}
+	return result
+}
+
+//newSARIFDefinitionResult creates result of the rule
+//that is located at the definition
+func newSARIFDefinitionResult(def *gounexport.Definition, ruleID string) *sarifResult {
+	result := new(sarifResult)
+	result.RuleID = ruleID
+	result.Level = "warning"
+	result.PartialFingerprints = map[string]string{"gounexportId/v1": def.ID}
+	result.Locations = []*sarifLocation{{
+		PhysicalLocation: &sarifPhysicalLocation{
+			ArtifactLocation: &sarifArtifactLocation{URI: fileURI(def.File)},
+			Region: &sarifRegion{
+				StartLine:  def.Line,
+				ByteOffset: def.Offset,
+				ByteLength: len(def.SimpleName),
+			},
+		},
+	}}
+	return result
+}
+
+//newSARIFFix collects replacements by calling UnexportWithNaming
+//with a rename function that only records them.
+func newSARIFFix(def *gounexport.Definition, allDefs map[string]*gounexport.Definition, naming *gounexport.Naming) (*sarifFix, error) {
+	changes := make(map[string]*sarifArtifactChange)
+	newName := ""
+	collect := func(file string, offset int, from string, to string) error {
+		change, ok := changes[file]
+		if !ok {
+			change = &sarifArtifactChange{ArtifactLocation: &sarifArtifactLocation{URI: fileURI(file)}}
+			changes[file] = change
+		}
+		change.Replacements = append(change.Replacements, &sarifReplacement{
+			DeletedRegion:   &sarifRegion{ByteOffset: offset, ByteLength: len(from)},
+			InsertedContent: &sarifMessage{Text: to},
+		})
+		newName = to
+		return nil
+	}
+	if err := gounexport.UnexportWithNaming(def, allDefs, naming, collect); err != nil {
+		return nil, err
+	}
+
+	fix := new(sarifFix)
+	fix.Description = &sarifMessage{Text: fmt.Sprintf("Rename %s to %s", def.SimpleName, newName)}
+	for _, change := range changes {
+		sort.Slice(change.Replacements, func(i, j int) bool {
+			return change.Replacements[i].DeletedRegion.ByteOffset < change.Replacements[j].DeletedRegion.ByteOffset
+		})
+		fix.ArtifactChanges = append(fix.ArtifactChanges, change)
+	}
+	sort.Slice(fix.ArtifactChanges, func(i, j int) bool {
+		return fix.ArtifactChanges[i].ArtifactLocation.URI < fix.ArtifactChanges[j].ArtifactLocation.URI
+	})
+	return fix, nil
+}
+
+//setCodePointColumns sets start and end columns of the region in code points.
+//Sources of files are cached in the sources map. Columns are left unset
+//if the file can't be read, so consumers rely on byteOffset and byteLength.
+func setCodePointColumns(region *sarifRegion, def *gounexport.Definition, sources map[string][]byte) {
+	src, ok := sources[def.File]
+	if !ok {
+		var err error
+		if src, err = ioutil.ReadFile(def.File); err != nil {
+			util.Warn("can't read [%s] to calculate columns: %v", def.File, err)
+		}
+		sources[def.File] = src
+	}
+	lineStart := def.Offset - (def.Col - 1)
+	end := def.Offset + len(def.SimpleName)
+	if lineStart < 0 || end > len(src) {
+		return
+	}
+	region.StartColumn = utf8.RuneCount(src[lineStart:def.Offset]) + 1
+	region.EndColumn = region.StartColumn + utf8.RuneCount(src[def.Offset:end])
+}
+
+func fileURI(file string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(file)}
+	return u.String()
+}
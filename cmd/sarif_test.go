@@ -0,0 +1,38 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dooman87/gounexport"
+)
+
+func TestSetCodePointColumns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sarif")
+	if err != nil {
+		t.Fatalf("can't create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := "package p\n\nvar ŁódźName, Ünused = 1, 2\n"
+	file := filepath.Join(dir, "p.go")
+	if err := ioutil.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatalf("can't write source: %v", err)
+	}
+
+	def := new(gounexport.Definition)
+	def.File = file
+	def.SimpleName = "Ünused"
+	def.Offset = strings.Index(src, def.SimpleName)
+	def.Line = 3
+	def.Col = def.Offset - strings.Index(src, "var") + 1
+
+	region := new(sarifRegion)
+	setCodePointColumns(region, def, make(map[string][]byte))
+	if region.StartColumn != 15 || region.EndColumn != 21 {
+		t.Errorf("expected columns 15-21, but found %d-%d", region.StartColumn, region.EndColumn)
+	}
+}
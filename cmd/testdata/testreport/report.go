@@ -0,0 +1,22 @@
+package testreport
+
+//Greeting is an example of unused constant
+const Greeting = "héllo"
+
+//Config is an example of unused type with used field
+type Config struct {
+	Name string
+}
+
+//Describe is an example of unused function
+func Describe(c Config) string {
+	return c.Name + greeting()
+}
+
+func greeting() string {
+	return Greeting
+}
+
+//Counter is declared after non-ASCII name, so its columns
+//in code points are different from byte columns
+var café, Counter = 1, 2
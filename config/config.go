@@ -0,0 +1,179 @@
+//Package config provides project configuration of gounexport.
+//
+//Configuration is stored in .gounexport.yaml (.yml) or .gounexport.toml
+//file that is discovered by walking up from the working directory.
+//For example,
+//  packages: ["./..."]
+//  consumers: ["../service"]
+//  excludes: ["Test*", "api/.*"]
+//  kinds: [func, type]
+//  contexts: ["linux/amd64", "windows/amd64"]
+//  tags: [integration]
+//  format: json
+//  ignoreTests: true
+//  dead: false
+//  rename: false
+//  verify: true
+//  naming: initialisms
+//
+//Relative paths are resolved from the directory of the configuration file.
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+
+	"github.com/dooman87/gounexport/util"
+)
+
+//FileNames are names of configuration files in the
+//order they are searched in each directory.
+var FileNames = []string{".gounexport.yaml", ".gounexport.yml", ".gounexport.toml"}
+
+//Config is a project configuration. Each field has the
+//same meaning as the flag of the command line tool.
+type Config struct {
+	//Packages to analyze if they are not passed as arguments
+	Packages []string `yaml:"packages" toml:"packages"`
+	//Consumers are parsed only to collect usages
+	Consumers []string `yaml:"consumers" toml:"consumers"`
+	//Excludes are regular expressions for names of
+	//definitions that shouldn't be unexported
+	Excludes []string `yaml:"excludes" toml:"excludes"`
+	//Kinds of definitions to report, all kinds if empty
+	Kinds []string `yaml:"kinds" toml:"kinds"`
+	//Contexts in format GOOS/GOARCH[,tag...]
+	Contexts []string `yaml:"contexts" toml:"contexts"`
+	//Tags are build tags that are added to each context
+	Tags []string `yaml:"tags" toml:"tags"`
+	//Format of the report: text, json or sarif
+	Format string `yaml:"format" toml:"format"`
+	//Out is a file to write the report
+	Out string `yaml:"out" toml:"out"`
+	//IgnoreTests makes usages from external test packages not count
+	IgnoreTests *bool `yaml:"ignoreTests" toml:"ignoreTests"`
+	//Dead makes definitions that are not used anywhere reported
+	Dead *bool `yaml:"dead" toml:"dead"`
+	//IncludeAPI makes exported definitions of
+	//packages that are not main reported as dead
+	IncludeAPI *bool `yaml:"includeAPI" toml:"includeAPI"`
+	//Transitive makes definitions that are used
+	//only by dead code reported as dead
+	Transitive *bool `yaml:"transitive" toml:"transitive"`
+	//Unreachable makes definitions that are not
+	//reachable from roots reported
+	Unreachable *bool `yaml:"unreachable" toml:"unreachable"`
+	//Roots are regular expressions for full names
+	//of roots of reachability
+	Roots []string `yaml:"roots" toml:"roots"`
+	//APIRoots makes exported definitions of
+	//packages that are not main roots
+	APIRoots *bool `yaml:"apiRoots" toml:"apiRoots"`
+	//Reflected makes definitions that are reachable
+	//through reflection reported
+	Reflected *bool `yaml:"reflected" toml:"reflected"`
+	//Strict makes analysis fail if there are type errors
+	Strict *bool `yaml:"strict" toml:"strict"`
+	//Rename makes unused definitions renamed in files
+	Rename *bool `yaml:"rename" toml:"rename"`
+	//Remove makes dead or unreachable definitions removed from files
+	Remove *bool `yaml:"remove" toml:"remove"`
+	//Verify makes packages type checked after renaming
+	Verify *bool `yaml:"verify" toml:"verify"`
+	//Patch is a file to write unified diff of renaming
+	Patch string `yaml:"patch" toml:"patch"`
+	//Naming is a strategy for new names: first or initialisms
+	Naming string `yaml:"naming" toml:"naming"`
+	//Names is a file with new names of definitions
+	Names string `yaml:"names" toml:"names"`
+	//FallbackPrefix is added to the original name if
+	//the new name conflicts with other identifier
+	FallbackPrefix string `yaml:"fallbackPrefix" toml:"fallbackPrefix"`
+	//FallbackSuffix is added to the new name if
+	//it conflicts with other identifier
+	FallbackSuffix string `yaml:"fallbackSuffix" toml:"fallbackSuffix"`
+
+	//File is a path to the configuration file
+	File string `yaml:"-" toml:"-"`
+}
+
+//Find walks up from dir and returns path to the first found
+//configuration file. Returns empty string if there is no
+//configuration file.
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, name := range FileNames {
+			file := filepath.Join(dir, name)
+			if info, err := os.Stat(file); err == nil && !info.IsDir() {
+				return file, nil
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+//Load reads configuration from the file. Format is determined
+//by the extension of the file. Unknown keys are errors to catch
+//misprints.
+func Load(file string) (*Config, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := new(Config)
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(content, cfg)
+	case ".toml":
+		var meta toml.MetaData
+		meta, err = toml.Decode(string(content), cfg)
+		if undecoded := meta.Undecoded(); err == nil && len(undecoded) > 0 {
+			err = fmt.Errorf("unknown keys %v", undecoded)
+		}
+	default:
+		err = fmt.Errorf("unknown format of configuration file, expected .yaml, .yml or .toml")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+
+	if cfg.File, err = filepath.Abs(file); err != nil {
+		return nil, err
+	}
+	util.Info("configuration loaded from [%s]", cfg.File)
+	return cfg, nil
+}
+
+//Package resolves package that is passed as relative directory
+//(started with dot) from the directory of the configuration file.
+//Package paths are returned as is.
+func (cfg *Config) Package(pkg string) string {
+	if !strings.HasPrefix(pkg, ".") {
+		return pkg
+	}
+	return filepath.Join(filepath.Dir(cfg.File), pkg)
+}
+
+//FilePath resolves relative path of the file from
+//the directory of the configuration file.
+func (cfg *Config) FilePath(path string) string {
+	if len(path) == 0 || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(filepath.Dir(cfg.File), path)
+}
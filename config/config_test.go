@@ -0,0 +1,122 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const yamlConfig = `packages: ["./..."]
+consumers: ["../service"]
+excludes: ["Test*"]
+kinds: [func]
+contexts: ["linux/amd64"]
+tags: [integration]
+format: json
+out: report.json
+ignoreTests: true
+verify: false
+`
+
+const tomlConfig = `packages = ["github.com/user/lib"]
+format = "sarif"
+rename = true
+`
+
+func TestFindAndLoadYAML(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	inner := filepath.Join(dir, "a", "b")
+	if err := os.MkdirAll(inner, 0755); err != nil {
+		t.Fatalf("error while creating dir: %v", err)
+	}
+	writeFile(filepath.Join(dir, ".gounexport.yaml"), yamlConfig, t)
+
+	file, err := Find(inner)
+	if err != nil {
+		t.Fatalf("error while searching config: %v", err)
+	}
+	if file != filepath.Join(dir, ".gounexport.yaml") {
+		t.Fatalf("expected config in %s, but found [%s]", dir, file)
+	}
+
+	cfg, err := Load(file)
+	if err != nil {
+		t.Fatalf("error while loading config: %v", err)
+	}
+	if len(cfg.Packages) != 1 || cfg.Package(cfg.Packages[0]) != filepath.Join(dir, "...") {
+		t.Errorf("expected package %s/..., but found %v", dir, cfg.Packages)
+	}
+	if len(cfg.Consumers) != 1 || cfg.Package(cfg.Consumers[0]) != filepath.Join(filepath.Dir(dir), "service") {
+		t.Errorf("expected consumer ../service, but found %v", cfg.Consumers)
+	}
+	if cfg.FilePath(cfg.Out) != filepath.Join(dir, "report.json") {
+		t.Errorf("expected out in %s, but found %s", dir, cfg.FilePath(cfg.Out))
+	}
+	if cfg.Format != "json" || len(cfg.Kinds) != 1 || len(cfg.Tags) != 1 || len(cfg.Contexts) != 1 {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if cfg.IgnoreTests == nil || !*cfg.IgnoreTests || cfg.Verify == nil || *cfg.Verify {
+		t.Errorf("expected ignoreTests and not verify, but found %v, %v", cfg.IgnoreTests, cfg.Verify)
+	}
+	if cfg.Rename != nil || cfg.Strict != nil {
+		t.Errorf("expected rename and strict to be not set, but found %v, %v", cfg.Rename, cfg.Strict)
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, ".gounexport.toml")
+	writeFile(file, tomlConfig, t)
+	cfg, err := Load(file)
+	if err != nil {
+		t.Fatalf("error while loading config: %v", err)
+	}
+	if cfg.Package(cfg.Packages[0]) != "github.com/user/lib" || cfg.Format != "sarif" || !*cfg.Rename {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+}
+
+func TestLoadUnknownKey(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	for name, content := range map[string]string{
+		".gounexport.yml":  "formt: json\n",
+		".gounexport.toml": "formt = \"json\"\n",
+	} {
+		file := filepath.Join(dir, name)
+		writeFile(file, content, t)
+		if _, err := Load(file); err == nil {
+			t.Errorf("expected error for unknown key in %s", name)
+		}
+	}
+}
+
+func TestFindNotFound(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	//Temp dir could be inside of the project with configuration
+	if file, err := Find(dir); err != nil || (len(file) > 0 && filepath.Dir(file) == dir) {
+		t.Errorf("expected no config in %s, but found [%s] %v", dir, file, err)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "gounexport-config")
+	if err != nil {
+		t.Fatalf("error while creating temp dir: %v", err)
+	}
+	return dir
+}
+
+func writeFile(file string, content string, t *testing.T) {
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("error while writing %s: %v", file, err)
+	}
+}
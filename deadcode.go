@@ -0,0 +1,189 @@
+package gounexport
+
+import (
+	"go/types"
+	"path/filepath"
+	"strings"
+
+	"github.com/dooman87/gounexport/util"
+)
+
+//FindDeadDefinitions returns definitions of packages that are not used
+//anywhere, even inside of their own package, so they could be deleted.
+//Unlike FindUnusedDefinitions, unexported definitions are returned
+//as well. Definitions are not dead if:
+// - Definition is main or init function, or test, benchmark, example or fuzz function
+// - Definition is a method that implements an interface
+// - Definition is an embedded field, a local variable or a parameter
+// - Definition is reachable through reflection or marked by KeepDirective
+// - Definition is exported and its package is not main, unless opts.IncludeAPI is true
+//If opts.Transitive is true, then usages inside of dead functions and
+//methods are not counted, so code that is reachable only from dead code
+//is dead too. Excludes, Kinds, IgnoreTestUsages and IncludeReflected
+//options are applied in the same way as for FindUnusedDefinitions.
+func FindDeadDefinitions(pkgs []string, defs map[string]*Definition, opts *Options) []*Definition {
+	directives := opts.Directives
+	if directives == nil {
+		directives = NewDirectives()
+	}
+
+	var candidates []*Definition
+	for _, def := range defs {
+		if isTestPackage(def) || !isInPackages(def, pkgs) || !isDeadCandidate(def) {
+			continue
+		}
+		if !opts.IncludeAPI && isLibraryAPI(def) {
+			continue
+		}
+		directives.load(def.File)
+		if isReflected(def, opts) || (!opts.Transitive && hasUsages(def, opts, nil)) {
+			continue
+		}
+		candidates = append(candidates, def)
+	}
+
+	dead := make(map[*Definition]bool)
+	if opts.Transitive {
+		findTransitiveDead(candidates, defs, opts, dead)
+	} else {
+		for _, def := range candidates {
+			dead[def] = true
+		}
+	}
+
+	var result []*Definition
+	for _, def := range candidates {
+		if dead[def] && !directives.keeps(def) && !isExcluded(def, opts.Excludes) && hasKind(def, opts.Kinds) {
+			util.Info("adding [%s] to dead list", def.Name)
+			result = append(result, def)
+		}
+	}
+	return result
+}
+
+//findTransitiveDead marks candidates as dead until there are no
+//candidates that are used only inside of dead functions.
+func findTransitiveDead(candidates []*Definition, defs map[string]*Definition, opts *Options, dead map[*Definition]bool) {
+	bodies := newBodyIndex(defs)
+	for changed := true; changed; {
+		changed = false
+		for _, def := range candidates {
+			if dead[def] {
+				continue
+			}
+			isLive := func(u *Usage) bool {
+				enclosing := bodies.enclosing(u)
+				return enclosing != def && !dead[enclosing]
+			}
+			if !hasUsages(def, opts, isLive) {
+				util.Debug("definition [%s] is used only by dead code", def.Name)
+				dead[def] = true
+				changed = true
+			}
+		}
+	}
+}
+
+//hasUsages returns true if definition has usages that are counted
+//with opts. If isLive is not nil, then only usages that are matched
+//by it are counted.
+func hasUsages(def *Definition, opts *Options, isLive func(*Usage) bool) bool {
+	for _, u := range def.Usages {
+		if !u.Pos.IsValid() || (opts.IgnoreTestUsages && u.isTest()) {
+			continue
+		}
+		if len(u.Evidence) > 0 && opts.IncludeReflected {
+			continue
+		}
+		if isLive == nil || isLive(u) {
+			return true
+		}
+	}
+	return false
+}
+
+//isDeadCandidate returns false for definitions that are
+//used implicitly, so they can't be dead.
+func isDeadCandidate(def *Definition) bool {
+	if def.SimpleName == "_" || len(def.File) == 0 {
+		return false
+	}
+	//Methods could be called through interfaces
+	if def.Kind == KindMethod && len(def.Interfaces) > 0 {
+		return false
+	}
+	if def.Kind == KindFunc {
+		if def.SimpleName == "main" || def.SimpleName == "init" {
+			return false
+		}
+		if strings.HasSuffix(def.File, "_test.go") && isTestFunc(def.SimpleName) {
+			return false
+		}
+	}
+
+	switch obj := def.obj.(type) {
+	case *types.Var:
+		//Embedded fields are used by promoted fields and methods
+		if obj.Embedded() {
+			return false
+		}
+		//Parameters, results and local variables
+		if !obj.IsField() && obj.Parent() != nil && obj.Pkg() != nil && obj.Parent() != obj.Pkg().Scope() {
+			return false
+		}
+	case *types.Const, *types.TypeName:
+		if obj.Parent() != nil && obj.Pkg() != nil && obj.Parent() != obj.Pkg().Scope() {
+			return false
+		}
+	case nil:
+		return false
+	}
+	return true
+}
+
+//isLibraryAPI returns true if definition is exported
+//and it's not in the main package
+func isLibraryAPI(def *Definition) bool {
+	return def.Exported && def.Pkg != nil && def.Pkg.Name() != "main"
+}
+
+func isTestFunc(name string) bool {
+	for _, prefix := range []string{"Test", "Benchmark", "Example", "Fuzz"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+//bodyIndex maps positions to functions and methods where they are located
+type bodyIndex struct {
+	files map[string][]*Definition
+}
+
+func newBodyIndex(defs map[string]*Definition) *bodyIndex {
+	index := new(bodyIndex)
+	index.files = make(map[string][]*Definition)
+	for _, def := range defs {
+		if def.bodyEnd > def.bodyStart {
+			file := filepath.Clean(def.File)
+			index.files[file] = append(index.files[file], def)
+		}
+	}
+	return index
+}
+
+//enclosing returns the innermost function or method that contains
+//the usage. Returns nil if usage is not inside of a function,
+//e.g. in initializer of package level variable.
+func (index *bodyIndex) enclosing(u *Usage) *Definition {
+	var result *Definition
+	for _, def := range index.files[filepath.Clean(u.Pos.Filename)] {
+		if def.bodyStart <= u.Pos.Offset && u.Pos.Offset < def.bodyEnd {
+			if result == nil || def.bodyStart > result.bodyStart {
+				result = def
+			}
+		}
+	}
+	return result
+}
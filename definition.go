@@ -7,24 +7,145 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"path/filepath"
 	"reflect"
 	"strings"
 )
 
 //GetDefinitions collects information about all (exported and unexported)
 //definitions and adapt them to Definition structure.
-//Returns map where key is full name (package + name) of symbol.
+//Returns map where key is ID of the definition, see Definition.ID.
+//Use FindDefinitionByName to get definition by its full name.
 func GetDefinitions(info *types.Info, fset *token.FileSet) map[string]*Definition {
 	ctx := newContext(fset)
 	ctx.defs = make(map[string]*Definition, 0)
 
+	processFiles(info, ctx)
+	processStructs(info, ctx)
 	processTypes(info, ctx)
 	processDefs(info, ctx)
 	processUses(info, ctx)
+	processReflection(info, ctx)
+	processDynamicUsages(info, ctx)
 
 	return ctx.defs
 }
 
+//MergeDefinitions adds definitions from src to dst. If definition
+//is already in dst, then usages and interfaces from src definition are
+//added to it. It's useful to combine definitions that were collected
+//for different build contexts. If definition is declared in another
+//place (e.g. foo_linux.go and foo_windows.go), then the declaration
+//is added as usage to rename it as well. Definitions with the same ID,
+//but different full names are different objects, so src definition is
+//added with the full name appended to its ID.
+func MergeDefinitions(dst map[string]*Definition, src map[string]*Definition) {
+	for id, srcDef := range src {
+		dstDef, ok := dst[id]
+		if ok && dstDef.Name != srcDef.Name {
+			util.Warn("definitions [%s] and [%s] have the same ID [%s]", dstDef.Name, srcDef.Name, id)
+			srcDef.ID = id + "@" + srcDef.Name
+			dstDef, ok = dst[srcDef.ID]
+		}
+		if !ok {
+			dst[srcDef.ID] = srcDef
+			continue
+		}
+
+		if srcDef.File != dstDef.File || srcDef.Offset != dstDef.Offset {
+			declaration := token.Position{Filename: srcDef.File, Offset: srcDef.Offset, Line: srcDef.Line, Column: srcDef.Col}
+			if declaration.IsValid() && !hasUsage(dstDef, &Usage{Pos: declaration}) {
+				pkgPath := ""
+				if srcDef.Pkg != nil {
+					pkgPath = srcDef.Pkg.Path()
+				}
+				dstDef.addUsage(declaration, pkgPath)
+			}
+		}
+
+		if len(dstDef.Reflection) == 0 {
+			dstDef.Reflection = srcDef.Reflection
+		}
+		for _, u := range srcDef.Usages {
+			if !hasUsage(dstDef, u) {
+				dstDef.Usages = append(dstDef.Usages, u)
+			}
+		}
+		for _, srcInterface := range srcDef.Interfaces {
+			if !hasInterface(dstDef, srcInterface.ID) {
+				dstDef.Interfaces = append(dstDef.Interfaces, srcInterface)
+			}
+		}
+	}
+
+	//Pointing interfaces to merged definitions
+	for _, def := range dst {
+		for i, iDef := range def.Interfaces {
+			if mergedDef, ok := dst[iDef.ID]; ok {
+				def.Interfaces[i] = mergedDef
+			}
+		}
+	}
+}
+
+func hasUsage(def *Definition, usage *Usage) bool {
+	for _, u := range def.Usages {
+		if u.Pos.Filename == usage.Pos.Filename && u.Pos.Offset == usage.Pos.Offset && u.Evidence == usage.Evidence {
+			return true
+		}
+	}
+	return false
+}
+
+func hasInterface(def *Definition, id string) bool {
+	for _, i := range def.Interfaces {
+		if i.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+//processFiles maps files to paths of their packages. Package
+//is taken from any object that is defined in the file. That is
+//the only way to distinguish external test package (package foo_test)
+//from the tested one, because they are located in the same directory.
+func processFiles(info *types.Info, ctx *context) {
+	for ident, obj := range info.Defs {
+		if obj == nil || obj.Pkg() == nil {
+			continue
+		}
+		file := ctx.fset.Position(ident.Pos()).Filename
+		if _, ok := ctx.filePkgs[file]; !ok {
+			ctx.filePkgs[file] = obj.Pkg().Path()
+			ctx.files[file] = obj.Pkg()
+		}
+	}
+}
+
+//processStructs fills positions of struct's fields (key) and struct
+//name (value) to map. Then we can extract struct name for fields when
+//will be analyze them. It should be done before any definition is
+//created, otherwise a field could get the same name as a type
+//(e.g. Nested *Nested) and be skipped as duplicate.
+func processStructs(info *types.Info, ctx *context) {
+	for _, obj := range info.Defs {
+		t, ok := obj.(*types.TypeName)
+		if !ok {
+			continue
+		}
+		//Fields of aliased named type are named by the type
+		if _, ok := types.Unalias(t.Type()).(*types.Named); ok && t.IsAlias() {
+			continue
+		}
+		if s, ok := t.Type().Underlying().(*types.Struct); ok {
+			for i := 0; i < s.NumFields(); i++ {
+				ctx.structs[posToStr(ctx.fset, s.Field(i).Pos())] = t.Name()
+			}
+		}
+	}
+}
+
 //processTypes is only filling interfaces from function signatures.
 func processTypes(info *types.Info, ctx *context) {
 	for _, t := range info.Types {
@@ -42,7 +163,7 @@ func processTypes(info *types.Info, ctx *context) {
 				if tuple := s.Params(); tuple != nil {
 					for i := 0; i < tuple.Len(); i++ {
 						v := tuple.At(i)
-						if types.IsInterface(v.Type()) {
+						if types.IsInterface(v.Type()) && !isTypeParam(v) {
 							addInterface(v, nil, ctx)
 						}
 					}
@@ -92,11 +213,20 @@ func processDefs(info *types.Info, ctx *context) {
 //where all params should be processed.
 func processUses(info *types.Info, ctx *context) {
 	for ident, obj := range info.Uses {
-		useName := getFullName(obj, ctx, false)
-		if ctx.defs[useName] != nil {
-			ctx.defs[useName].addUsage(ctx.fset.Position(ident.Pos()))
+		if _, ok := obj.(*types.TypeName); ok && isTypeParam(obj) {
+			continue
+		}
+		pos := ctx.fset.Position(ident.Pos())
+		usagePkg := ctx.filePkgs[pos.Filename]
+		useID := objectID(obj, ctx, false)
+		if ctx.defs[useID] != nil {
+			u := ctx.defs[useID].addUsage(pos, usagePkg)
+			if pkg := ctx.files[pos.Filename]; pkg != nil {
+				u.scope = pkg.Scope().Innermost(ident.Pos())
+				u.ident = ident.Pos()
+			}
 		} else {
-			util.Warn("can't find usage for [%s] %s\n\tObject definition - %s", useName, posToStr(ctx.fset, ident.Pos()), posToStr(ctx.fset, obj.Pos()))
+			util.Warn("can't find usage for [%s] %s\n\tObject definition - %s", useID, posToStr(ctx.fset, ident.Pos()), posToStr(ctx.fset, obj.Pos()))
 		}
 		switch obj.Type().(type) {
 		case *types.Signature:
@@ -104,9 +234,9 @@ func processUses(info *types.Info, ctx *context) {
 			if tuple := s.Params(); tuple != nil {
 				for i := 0; i < tuple.Len(); i++ {
 					v := tuple.At(i)
-					useName := getFullName(v, ctx, true)
-					if ctx.defs[useName] != nil {
-						ctx.defs[useName].addUsage(ctx.fset.Position(ident.Pos()))
+					useID := objectID(v, ctx, true)
+					if ctx.defs[useID] != nil {
+						ctx.defs[useID].addUsage(pos, usagePkg)
 					}
 				}
 			}
@@ -117,7 +247,15 @@ func processUses(info *types.Info, ctx *context) {
 func addInterface(obj types.Object, ident *ast.Ident, ctx *context) {
 	interfac := obj.Type().Underlying().(*types.Interface)
 
-	def := createDef(obj, ident, ctx, true)
+	//Vars and params of named interface types are adding
+	//definition of the type, but not the var itself
+	typeObj, typeIdent := obj, ident
+	if _, ok := obj.(*types.TypeName); !ok {
+		if named, ok := obj.Type().(*types.Named); ok {
+			typeObj, typeIdent = named.Origin().Obj(), nil
+		}
+	}
+	def := createDef(typeObj, typeIdent, ctx, true)
 	updateContext(ctx, def, ident, obj)
 
 	util.Debug("adding interface [%s] [%v] [%v] [%v]", def.Name, def.Pkg, obj.Type().Underlying(), obj.Type())
@@ -148,7 +286,12 @@ func isValidObject(obj types.Object, ident *ast.Ident, ctx *context) bool {
 	if typeOf == nil {
 		return false
 	}
-	if ctx.defs[fullName] != nil {
+	//Type parameters are visible only inside of the
+	//generic declaration, so there is nothing to unexport
+	if _, ok := obj.(*types.TypeName); ok && isTypeParam(obj) {
+		return false
+	}
+	if ctx.defs[objectID(obj, ctx, false)] != nil {
 		return false
 	}
 	return true
@@ -162,21 +305,6 @@ func updateContext(ctx *context, def *Definition, ident *ast.Ident, obj types.Ob
 	case *types.Func:
 		//Processing funcs later to be sure that all info about interfaces already filled
 		ctx.funcs = append(ctx.funcs, newObjectWithIdent(obj, ident))
-	case *types.TypeName:
-		//If the underlying type is struct, then filling
-		//positions of struct's fields (key) and struct name(value)
-		//to map. Then we can extract struct name for fields when
-		//will be analyze them.
-		t := obj.(*types.TypeName)
-		underlyingType := t.Type().Underlying()
-		switch underlyingType.(type) {
-		case *types.Struct:
-			s := underlyingType.(*types.Struct)
-			for i := 0; i < s.NumFields(); i++ {
-				field := s.Field(i)
-				ctx.structs[posToStr(ctx.fset, field.Pos())] = obj.Name()
-			}
-		}
 	}
 
 	//Check for interfaces
@@ -207,39 +335,55 @@ func isFunc(obj types.Object) bool {
 }
 
 func createDef(obj types.Object, ident *ast.Ident, ctx *context, isType bool) *Definition {
-	fullName := getFullName(obj, ctx, isType)
+	id := objectID(obj, ctx, isType)
 
-	if def, ok := ctx.defs[fullName]; ok {
+	if def, ok := ctx.defs[id]; ok {
+		//Definition could be created before its declaration,
+		//e.g. interface of a function parameter
+		if len(def.File) == 0 && ident != nil && ident.Pos() == obj.Pos() {
+			setPosition(def, ident, ctx)
+		}
 		return def
 	}
 
 	def := new(Definition)
-	def.Name = fullName
+	def.ID = id
+	def.Name = getFullName(obj, ctx, isType)
+	def.obj = obj
 	def.Pkg = obj.Pkg()
 	def.Exported = obj.Exported()
 	def.TypeOf = reflect.TypeOf(obj)
+	def.Kind = kindOf(obj)
 	def.SimpleName = obj.Name()
 	def.Usages = make([]*Usage, 0)
 	def.Interfaces = make([]*Definition, 0)
 
 	if ident != nil {
-		position := ctx.fset.Position(ident.Pos())
-		def.File = position.Filename
-		def.Line = position.Line
-		def.Offset = position.Offset
-		def.Col = position.Column
+		setPosition(def, ident, ctx)
+	}
+	if f, ok := obj.(*types.Func); ok && f.Scope() != nil {
+		def.bodyStart = ctx.fset.Position(f.Scope().Pos()).Offset
+		def.bodyEnd = ctx.fset.Position(f.Scope().End()).Offset
 	}
 
 	if !types.IsInterface(obj.Type()) {
 		fillInterfaces(def, obj, ctx)
 	}
 
-	ctx.defs[def.Name] = def
+	ctx.defs[def.ID] = def
 	logDefinition(def, obj, ident, ctx)
 
 	return def
 }
 
+func setPosition(def *Definition, ident *ast.Ident, ctx *context) {
+	position := ctx.fset.Position(ident.Pos())
+	def.File = position.Filename
+	def.Line = position.Line
+	def.Offset = position.Offset
+	def.Col = position.Column
+}
+
 func fillInterfaces(def *Definition, obj types.Object, ctx *context) {
 	switch obj.(type) {
 	case *types.TypeName:
@@ -264,8 +408,7 @@ func fillInterfaces(def *Definition, obj types.Object, ctx *context) {
 				//searching for current function in each interface.
 				//If found, then adding method's definition to function's
 				//interfaces
-				recvTypeName := strings.Replace(s.Recv().Type().String(), "*", "", 1)
-				if typeDef, ok := ctx.defs[recvTypeName]; ok {
+				if typeDef, ok := recvTypeDef(s.Recv(), ctx); ok {
 					for _, iDef := range typeDef.Interfaces {
 						def.Interfaces = append(def.Interfaces, iDef)
 						if methodDef := lookupMethod(def, iDef, ctx); methodDef != nil {
@@ -281,21 +424,24 @@ func fillInterfaces(def *Definition, obj types.Object, ctx *context) {
 }
 
 func lookupMethod(def *Definition, ifaceDef *Definition, ctx *context) *Definition {
-	methodName := ifaceDef.Name + "." + def.SimpleName
-	externalInterfaceMethodName := "interface." + def.SimpleName
 	def.Interfaces = append(def.Interfaces, ifaceDef)
-	if methodDef := ctx.defs[methodName]; methodDef != nil {
-		return methodDef
-	} else if methodDef := ctx.defs[externalInterfaceMethodName]; methodDef != nil {
-		return methodDef
-	} else {
-		util.Debug("can't find method [%s]", methodName)
+	if ifaceDef.obj != nil {
+		if interfac, ok := ifaceDef.obj.Type().Underlying().(*types.Interface); ok {
+			for i := 0; i < interfac.NumMethods(); i++ {
+				if m := interfac.Method(i); m.Name() == def.SimpleName {
+					return ctx.defs[objectID(m, ctx, false)]
+				}
+			}
+		}
 	}
+	util.Debug("can't find method [%s.%s]", ifaceDef.Name, def.SimpleName)
 	return nil
 }
 
 func implements(t types.Type, interfac *types.Interface, pkg *types.Package) bool {
-	if interfac == nil || t == nil || interfac.Empty() {
+	//Constraint interfaces (e.g. ~int | ~string) are not empty,
+	//but they have no methods to implement
+	if interfac == nil || t == nil || interfac.Empty() || interfac.NumMethods() == 0 {
 		return false
 	}
 	if types.Implements(t, interfac) {
@@ -321,14 +467,24 @@ func getFullName(obj types.Object, ctx *context, isType bool) string {
 		return ""
 	}
 	if isType {
+		if named, ok := obj.Type().(*types.Named); ok {
+			return namedTypeName(named)
+		}
 		return obj.Type().String()
 	}
 
 	result := ""
 
-	switch obj.(type) {
+	switch obj := obj.(type) {
 	case *types.Func:
-		f := obj.(*types.Func)
+		//Instantiated methods of generic types are
+		//resolved to the generic ones
+		f := obj.Origin()
+		if s, ok := f.Type().(*types.Signature); ok && s.Recv() != nil {
+			if named := recvNamed(s.Recv()); named != nil {
+				return namedTypeName(named) + "." + f.Name()
+			}
+		}
 		r := strings.NewReplacer("(", "", "*", "", ")", "")
 		result = r.Replace(f.FullName())
 	default:
@@ -347,6 +503,162 @@ func getFullName(obj types.Object, ctx *context, isType bool) string {
 	return result
 }
 
+//namedTypeName returns full name of the named type without type
+//arguments, so instantiations (e.g. List[int]) have the same name as
+//the generic type.
+func namedTypeName(named *types.Named) string {
+	obj := named.Origin().Obj()
+	if obj.Pkg() == nil {
+		return obj.Name()
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
+
+//recvNamed returns named type of the receiver. Returns nil
+//if it's not a named type, e.g. method of interface literal.
+func recvNamed(recv *types.Var) *types.Named {
+	t := recv.Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, _ := t.(*types.Named)
+	return named
+}
+
+//recvTypeDef returns definition of the receiver's type
+func recvTypeDef(recv *types.Var, ctx *context) (*Definition, bool) {
+	named := recvNamed(recv)
+	if named == nil {
+		return nil, false
+	}
+	def, ok := ctx.defs[objectID(named.Origin().Obj(), ctx, false)]
+	return def, ok
+}
+
+//objectID returns canonical identifier of the object. It's built from
+//the package path and names from the package scope to the object, e.g.
+//github.com/user/pkg#T.Method for the method of type T and
+//github.com/user/pkg#Config.Server.Port for the field of anonymous struct.
+//It doesn't depend on positions and order of declarations, so it's the
+//same in all build contexts and on each run. Objects that can't be reached
+//from the package scope by names (local variables, parameters) are
+//identified by the file and offset.
+//If isType is true, then the type of the object is identified.
+func objectID(obj types.Object, ctx *context, isType bool) string {
+	if isType {
+		named, ok := obj.Type().(*types.Named)
+		if !ok {
+			return "type:" + obj.Type().String()
+		}
+		obj = named.Origin().Obj()
+	}
+	switch o := obj.(type) {
+	case *types.Func:
+		obj = o.Origin()
+	case *types.Var:
+		obj = o.Origin()
+	}
+
+	pkgPath := ""
+	if obj.Pkg() != nil {
+		pkgPath = obj.Pkg().Path()
+		if path, ok := memberPath(obj, ctx); ok {
+			return pkgPath + "#" + path
+		}
+	}
+	if pos := ctx.fset.Position(obj.Pos()); pos.IsValid() {
+		return fmt.Sprintf("%s#%s:%d", pkgPath, filepath.Base(pos.Filename), pos.Offset)
+	}
+	return pkgPath + "#" + getFullName(obj, ctx, false)
+}
+
+//memberPath returns names from the package scope to the object
+//separated by dots. Methods are prefixed by the receiver type name,
+//fields and interface methods by the path of the type where they
+//are declared.
+func memberPath(obj types.Object, ctx *context) (string, bool) {
+	scope := obj.Pkg().Scope()
+	if obj.Parent() == scope {
+		return obj.Name(), true
+	}
+	if f, ok := obj.(*types.Func); ok {
+		if sig, ok := f.Type().(*types.Signature); ok && sig.Recv() != nil {
+			if named := recvNamed(sig.Recv()); named != nil && named.Obj().Parent() == scope {
+				return named.Origin().Obj().Name() + "." + f.Name(), true
+			}
+		}
+	}
+
+	if !ctx.indexedPkgs[obj.Pkg()] {
+		ctx.indexedPkgs[obj.Pkg()] = true
+		for _, name := range scope.Names() {
+			member := scope.Lookup(name)
+			t := member.Type()
+			//Members of aliased named type are added with its name
+			if typeName, ok := member.(*types.TypeName); ok && typeName.IsAlias() {
+				t = types.Unalias(t)
+			} else if ok {
+				t = t.Underlying()
+			}
+			addMemberPaths(name, t, ctx.memberPaths)
+		}
+	}
+	path, ok := ctx.memberPaths[obj]
+	return path, ok
+}
+
+//addMemberPaths adds paths of fields and interface methods that are
+//declared in the type. Named types are not visited, because their
+//members are added with their own names.
+func addMemberPaths(prefix string, t types.Type, paths map[types.Object]string) {
+	switch t := t.(type) {
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			field := t.Field(i)
+			//Blank fields can't be distinguished by names
+			if field.Name() == "_" {
+				continue
+			}
+			path := prefix + "." + field.Name()
+			paths[field] = path
+			addMemberPaths(path, field.Type(), paths)
+		}
+	case *types.Interface:
+		for i := 0; i < t.NumExplicitMethods(); i++ {
+			method := t.ExplicitMethod(i)
+			paths[method] = prefix + "." + method.Name()
+		}
+	case *types.Pointer:
+		addMemberPaths(prefix, t.Elem(), paths)
+	case *types.Slice:
+		addMemberPaths(prefix, t.Elem(), paths)
+	case *types.Array:
+		addMemberPaths(prefix, t.Elem(), paths)
+	case *types.Chan:
+		addMemberPaths(prefix, t.Elem(), paths)
+	case *types.Map:
+		addMemberPaths(prefix, t.Elem(), paths)
+	}
+}
+
+//FindDefinitionByName returns definition with the full
+//name (see Definition.Name) or nil if there is no such one.
+func FindDefinitionByName(defs map[string]*Definition, name string) *Definition {
+	for _, def := range defs {
+		if def.Name == name {
+			return def
+		}
+	}
+	return nil
+}
+
+//isTypeParam returns true if obj is a type parameter of
+//generic type or function, e.g. T in List[T any].
+func isTypeParam(obj types.Object) bool {
+	_, ok := obj.Type().(*types.TypeParam)
+	return ok
+}
+
 func logDefinition(def *Definition, obj types.Object, ident *ast.Ident, ctx *context) {
 	if ident == nil {
 		return
@@ -28,3 +28,20 @@ func TestGetDefinitionsUnimported(t *testing.T) {
 		t.Errorf("expected 1 exported definitions, but found %d", len(defs))
 	}
 }
+
+func TestMergeDefinitionsDifferentNames(t *testing.T) {
+	dst := map[string]*gounexport.Definition{
+		"pkg#x.go:10": {ID: "pkg#x.go:10", Name: "pkg.A", SimpleName: "A"},
+	}
+	src := map[string]*gounexport.Definition{
+		"pkg#x.go:10": {ID: "pkg#x.go:10", Name: "pkg.B", SimpleName: "B"},
+	}
+	gounexport.MergeDefinitions(dst, src)
+
+	if len(dst) != 2 {
+		t.Fatalf("expected 2 definitions, but found %d", len(dst))
+	}
+	if dst["pkg#x.go:10"].Name != "pkg.A" || dst["pkg#x.go:10@pkg.B"].Name != "pkg.B" {
+		t.Errorf("expected definitions with different names to be kept separately, but found %v", dst)
+	}
+}
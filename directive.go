@@ -0,0 +1,212 @@
+package gounexport
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/dooman87/gounexport/util"
+)
+
+//KeepDirective marks definition as intentionally exported. It could be
+//placed in the doc comment of the declaration or at the end of the line:
+//  //Plugin is loaded by name
+//  //gounexport:keep
+//  func Plugin() {}
+//
+//  type Config struct {
+//  	Name string //gounexport:keep used by reflection
+//  }
+//Directive in the doc comment of grouped declaration (var, const, type)
+//keeps all definitions of the group. Directive before the package clause
+//keeps all definitions of the file.
+const KeepDirective = "//gounexport:keep"
+
+//Directive is a keep directive in the source file
+type Directive struct {
+	//Pos is a position of the directive
+	Pos token.Position
+	//FileLevel is true if directive keeps all definitions of the file
+	FileLevel bool
+}
+
+//Directives contains keep directives of files. Files are parsed
+//on demand when definitions are checked. It's tracking which
+//directives are suppressing definitions to find stale ones.
+type Directives struct {
+	files map[string]*fileDirectives
+	used  map[*Directive]bool
+}
+
+type fileDirectives struct {
+	fileLevel *Directive
+	//decls are directives by offsets of identifiers
+	decls map[int]*Directive
+	all   []*Directive
+}
+
+//NewDirectives creates empty directives
+func NewDirectives() *Directives {
+	directives := new(Directives)
+	directives.files = make(map[string]*fileDirectives)
+	directives.used = make(map[*Directive]bool)
+	return directives
+}
+
+//load parses directives of the file if it was not parsed before
+func (directives *Directives) load(file string) *fileDirectives {
+	if len(file) == 0 {
+		return nil
+	}
+	if fileDirs, ok := directives.files[file]; ok {
+		return fileDirs
+	}
+	fileDirs, err := parseDirectives(file)
+	if err != nil {
+		util.Warn("can't read directives from [%s]: %v", file, err)
+	}
+	directives.files[file] = fileDirs
+	return fileDirs
+}
+
+//keeps returns true if definition is marked by directive.
+//Directive is marked as used in this case.
+func (directives *Directives) keeps(def *Definition) bool {
+	fileDirs := directives.load(def.File)
+	if fileDirs == nil {
+		return false
+	}
+	directive := fileDirs.decls[def.Offset]
+	if directive == nil {
+		directive = fileDirs.fileLevel
+	}
+	if directive == nil {
+		return false
+	}
+	util.Info("definition [%s] is kept by directive at %s", def.Name, directive.Pos)
+	directives.used[directive] = true
+	return true
+}
+
+//Stale returns directives that are not suppressing any unused
+//definition. Only directives from files of checked definitions
+//are returned.
+func (directives *Directives) Stale() []*Directive {
+	var result []*Directive
+	for _, fileDirs := range directives.files {
+		if fileDirs == nil {
+			continue
+		}
+		for _, directive := range fileDirs.all {
+			if !directives.used[directive] {
+				result = append(result, directive)
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Pos.Filename != result[j].Pos.Filename {
+			return result[i].Pos.Filename < result[j].Pos.Filename
+		}
+		return result[i].Pos.Offset < result[j].Pos.Offset
+	})
+	return result
+}
+
+//parseDirectives parses file with comments and maps directives
+//to identifiers of declarations that they are keeping.
+func parseDirectives(file string) (*fileDirectives, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	result := new(fileDirectives)
+	result.decls = make(map[int]*Directive)
+	found := make(map[*ast.Comment]*Directive)
+	directive := func(groups ...*ast.CommentGroup) *Directive {
+		for _, cg := range groups {
+			if cg == nil {
+				continue
+			}
+			for _, c := range cg.List {
+				if c.Text != KeepDirective && !strings.HasPrefix(c.Text, KeepDirective+" ") {
+					continue
+				}
+				if d, ok := found[c]; ok {
+					return d
+				}
+				d := new(Directive)
+				d.Pos = fset.Position(c.Pos())
+				found[c] = d
+				result.all = append(result.all, d)
+				return d
+			}
+		}
+		return nil
+	}
+	keep := func(d *Directive, idents ...*ast.Ident) {
+		if d == nil {
+			return
+		}
+		for _, ident := range idents {
+			result.decls[fset.Position(ident.Pos()).Offset] = d
+		}
+	}
+
+	for _, cg := range f.Comments {
+		if cg.Pos() < f.Package {
+			if d := directive(cg); d != nil {
+				d.FileLevel = true
+				result.fileLevel = d
+			}
+		}
+	}
+
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			keep(directive(decl.Doc), decl.Name)
+		case *ast.GenDecl:
+			groupDirective := directive(decl.Doc)
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					d := directive(spec.Doc, spec.Comment)
+					if d == nil {
+						d = groupDirective
+					}
+					keep(d, spec.Name)
+					keepFields(spec.Type, directive, keep)
+				case *ast.ValueSpec:
+					d := directive(spec.Doc, spec.Comment)
+					if d == nil {
+						d = groupDirective
+					}
+					keep(d, spec.Names...)
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+//keepFields maps directives of struct fields and interface methods
+func keepFields(typ ast.Expr, directive func(...*ast.CommentGroup) *Directive,
+	keep func(*Directive, ...*ast.Ident)) {
+	var fields *ast.FieldList
+	switch typ := typ.(type) {
+	case *ast.StructType:
+		fields = typ.Fields
+	case *ast.InterfaceType:
+		fields = typ.Methods
+	}
+	if fields == nil {
+		return
+	}
+	for _, field := range fields.List {
+		keep(directive(field.Doc, field.Comment), field.Names...)
+	}
+}
@@ -0,0 +1,201 @@
+package gounexport
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"io/ioutil"
+	"path/filepath"
+	"text/template/parse"
+
+	"github.com/dooman87/gounexport/util"
+)
+
+//processDynamicUsages adds usages of methods and fields that are
+//resolved by name at runtime, so they are not in info.Uses:
+// - names that are passed to reflect MethodByName and FieldByName as constants;
+// - fields and methods that are used in templates. Templates are parsed
+//   from constant strings and files that are passed to Parse, ParseFiles
+//   and ParseGlob of text/template and html/template.
+//The receiver is unknown, so all exported methods or fields with the
+//same name are used. Such usages have Evidence, see Usage.
+func processDynamicUsages(info *types.Info, ctx *context) {
+	methods := make(map[string][]*Definition)
+	fields := make(map[string][]*Definition)
+	for _, def := range ctx.defs {
+		if !def.Exported {
+			continue
+		}
+		switch def.Kind {
+		case KindMethod, KindInterfaceMethod:
+			methods[def.SimpleName] = append(methods[def.SimpleName], def)
+		case KindField:
+			fields[def.SimpleName] = append(fields[def.SimpleName], def)
+		}
+	}
+
+	for expr := range info.Types {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			continue
+		}
+		f, ok := info.Uses[sel.Sel].(*types.Func)
+		if !ok || f.Pkg() == nil {
+			continue
+		}
+
+		add := func(arg ast.Expr, defs []*Definition, evidence string) {
+			pos := ctx.fset.Position(arg.Pos())
+			for _, def := range defs {
+				util.Info("definition [%s] is used dynamically: %s", def.Name, evidence)
+				def.addDynamicUsage(pos, ctx.filePkgs[pos.Filename], evidence)
+			}
+		}
+
+		switch f.Pkg().Path() {
+		case "reflect":
+			if len(call.Args) != 1 {
+				continue
+			}
+			name, ok := constantString(call.Args[0], info)
+			if !ok {
+				continue
+			}
+			evidence := fmt.Sprintf("%s(%q)", f.FullName(), name)
+			switch f.Name() {
+			case "MethodByName":
+				add(call.Args[0], methods[name], evidence)
+			case "FieldByName":
+				add(call.Args[0], fields[name], evidence)
+			}
+		case "text/template", "html/template":
+			for i, arg := range call.Args {
+				source, text := templateSource(f.Name(), i, arg, info, ctx)
+				for _, t := range text {
+					for _, ident := range templateIdents(t) {
+						evidence := fmt.Sprintf("{{.%s}} in template %s", ident, source)
+						add(arg, append(methods[ident], fields[ident]...), evidence)
+					}
+				}
+			}
+		}
+	}
+}
+
+//templateSource returns source of the template and texts of templates
+//if argument with index i of template function with the name is
+//a constant string. File names are resolved from the directory of
+//the file where the function is called.
+func templateSource(name string, i int, arg ast.Expr, info *types.Info, ctx *context) (string, []string) {
+	value, ok := constantString(arg, info)
+	if !ok {
+		return "", nil
+	}
+
+	var files []string
+	dir := filepath.Dir(ctx.fset.Position(arg.Pos()).Filename)
+	switch {
+	case name == "Parse" && i == 0:
+		return "string", []string{value}
+	case name == "ParseFiles":
+		files = []string{value}
+	case name == "ParseGlob" && i == 0:
+		files, _ = filepath.Glob(filepath.Join(dir, value))
+	default:
+		return "", nil
+	}
+
+	var texts []string
+	for _, f := range files {
+		if !filepath.IsAbs(f) {
+			f = filepath.Join(dir, f)
+		}
+		content, err := ioutil.ReadFile(f)
+		if err != nil {
+			util.Debug("can't read template [%s]: %v", f, err)
+			continue
+		}
+		texts = append(texts, string(content))
+	}
+	return value, texts
+}
+
+//templateIdents parses template and returns names of
+//fields and methods that are used in it.
+func templateIdents(text string) []string {
+	tree := parse.New("")
+	tree.Mode = parse.SkipFuncCheck
+	trees := make(map[string]*parse.Tree)
+	if _, err := tree.Parse(text, "", "", trees); err != nil {
+		util.Debug("can't parse template: %v", err)
+		return nil
+	}
+
+	var idents []string
+	add := func(names ...string) {
+		idents = append(idents, names...)
+	}
+	for _, t := range trees {
+		walkTemplate(t.Root, add)
+	}
+	return idents
+}
+
+func walkTemplate(node parse.Node, add func(...string)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkTemplate(child, add)
+		}
+	case *parse.ActionNode:
+		walkTemplate(n.Pipe, add)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkTemplate(cmd, add)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkTemplate(arg, add)
+		}
+	case *parse.FieldNode:
+		add(n.Ident...)
+	case *parse.ChainNode:
+		walkTemplate(n.Node, add)
+		add(n.Field...)
+	case *parse.VariableNode:
+		add(n.Ident[1:]...)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, add)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, add)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, add)
+	case *parse.TemplateNode:
+		walkTemplate(n.Pipe, add)
+	}
+}
+
+func walkBranch(n *parse.BranchNode, add func(...string)) {
+	walkTemplate(n.Pipe, add)
+	walkTemplate(n.List, add)
+	walkTemplate(n.ElseList, add)
+}
+
+func constantString(expr ast.Expr, info *types.Info) (string, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
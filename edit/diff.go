@@ -0,0 +1,233 @@
+package edit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//diffContext is a number of unchanged lines around changes
+const diffContext = 3
+
+type diffLine struct {
+	//' ' for unchanged line, '-' for deleted and '+' for inserted
+	kind byte
+	text string
+}
+
+//Diff returns unified diff between original (a) and changed (b)
+//content of the file. Name is used in the header with a/ and b/
+//prefixes as git does. Returns empty string if there are no changes.
+func Diff(name string, a []byte, b []byte) string {
+	lines := diffLines(splitLines(string(a)), splitLines(string(b)))
+
+	var hunks []string
+	for i := 0; i < len(lines); {
+		for i < len(lines) && lines[i].kind == ' ' {
+			i++
+		}
+		if i == len(lines) {
+			break
+		}
+
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].kind != ' ' {
+				end++
+				continue
+			}
+			unchanged := end
+			for unchanged < len(lines) && lines[unchanged].kind == ' ' {
+				unchanged++
+			}
+			if unchanged == len(lines) || unchanged-end > 2*diffContext {
+				end += diffContext
+				if end > len(lines) {
+					end = len(lines)
+				}
+				break
+			}
+			end = unchanged
+		}
+
+		hunks = append(hunks, hunk(lines, start, end))
+		i = end
+	}
+
+	if len(hunks) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("--- a/%s\n+++ b/%s\n", name, name) + strings.Join(hunks, "")
+}
+
+//hunk formats lines[start:end] as a hunk of unified diff
+func hunk(lines []diffLine, start int, end int) string {
+	aStart, bStart := 1, 1
+	for _, l := range lines[0:start] {
+		if l.kind != '+' {
+			aStart++
+		}
+		if l.kind != '-' {
+			bStart++
+		}
+	}
+
+	aLen, bLen := 0, 0
+	body := ""
+	for _, l := range lines[start:end] {
+		if l.kind != '+' {
+			aLen++
+		}
+		if l.kind != '-' {
+			bLen++
+		}
+		body += string(l.kind) + l.text
+		if !strings.HasSuffix(l.text, "\n") {
+			body += "\n\\ No newline at end of file\n"
+		}
+	}
+
+	//Empty range is pointing to the line before
+	if aLen == 0 {
+		aStart--
+	}
+	if bLen == 0 {
+		bStart--
+	}
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen) + body
+}
+
+//diffLines returns the shortest list of deletions and insertions
+//that transforms a to b. Deleted lines go before inserted ones in
+//each group of changes.
+func diffLines(a []string, b []string) []diffLine {
+	lines := myersDiff(a, b, nil)
+	for i := 0; i < len(lines); {
+		if lines[i].kind == ' ' {
+			i++
+			continue
+		}
+		end := i
+		for end < len(lines) && lines[end].kind != ' ' {
+			end++
+		}
+		sort.SliceStable(lines[i:end], func(x, y int) bool {
+			return lines[i+x].kind == '-' && lines[i+y].kind == '+'
+		})
+		i = end
+	}
+	return lines
+}
+
+//myersDiff appends the shortest edit script of a and b to result.
+//It's the linear space variation of Myers algorithm: common prefix
+//and suffix are trimmed, then the middle snake of the script is found
+//and parts before and after it are diffed recursively.
+func myersDiff(a []string, b []string, result []diffLine) []diffLine {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	for _, l := range a[0:prefix] {
+		result = append(result, diffLine{' ', l})
+	}
+	changedA, changedB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	switch {
+	case len(changedA) == 0:
+		for _, l := range changedB {
+			result = append(result, diffLine{'+', l})
+		}
+	case len(changedB) == 0:
+		for _, l := range changedA {
+			result = append(result, diffLine{'-', l})
+		}
+	default:
+		//Both parts are not empty and their first and last lines are
+		//different, so the script has at least 2 edits and each part
+		//around the middle snake is shorter than the whole script
+		x, y, u, v := middleSnake(changedA, changedB)
+		result = myersDiff(changedA[0:x], changedB[0:y], result)
+		for _, l := range changedA[x:u] {
+			result = append(result, diffLine{' ', l})
+		}
+		result = myersDiff(changedA[u:], changedB[v:], result)
+	}
+	for _, l := range a[len(a)-suffix:] {
+		result = append(result, diffLine{' ', l})
+	}
+	return result
+}
+
+//middleSnake returns the middle snake of the shortest edit script
+//of a and b: a[x:u] is equal to b[y:v]. Paths are searched from
+//the start and from the end at the same time until they overlap.
+func middleSnake(a []string, b []string) (int, int, int, int) {
+	n, m := len(a), len(b)
+	delta := n - m
+	odd := delta%2 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	//forward[k] is the furthest x on diagonal k = x - y from the start,
+	//backward[k] is the same for reversed a and b
+	forward := make([]int, 2*max+3)
+	backward := make([]int, 2*max+3)
+
+	for d := 0; d <= max; d++ {
+		for k := -d; k <= d; k += 2 {
+			x := forward[offset+k-1] + 1
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			if reverseK := delta - k; odd && reverseK >= -(d-1) && reverseK <= d-1 {
+				if x+backward[offset+reverseK] >= n {
+					return startX, startY, x, y
+				}
+			}
+		}
+		for k := -d; k <= d; k += 2 {
+			x := backward[offset+k-1] + 1
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			}
+			y := x - k
+			startX, startY := x, y
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			if forwardK := delta - k; !odd && forwardK >= -d && forwardK <= d {
+				if x+forward[offset+forwardK] >= n {
+					return n - x, m - y, n - startX, m - startY
+				}
+			}
+		}
+	}
+	//Unreachable, paths always overlap when d is (n + m) / 2
+	return 0, 0, 0, 0
+}
+
+//splitLines splits content to lines keeping line breaks
+func splitLines(content string) []string {
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[0 : len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,286 @@
+//Package edit provides an ability to collect changes of source files
+//in memory and apply them later.
+//
+//Set.Add has the same signature as rename function that is
+//required by gounexport.Unexport, so all renames could be collected
+//without touching files. For example,
+//  set := edit.NewSet()
+//  err := gounexport.Unexport(def, defs, set.Add)
+//  diff, err := set.Diff()
+//
+//Edits of each file are applied from the end of the file, so
+//replacements with different length don't break offsets of
+//other edits. All offsets should point to the original content.
+//
+//Set.Commit writes all changed files at once and restores them
+//if any of files can't be written.
+//
+//Formatters are called for each changed file after edits are
+//applied, e.g. to remove unused imports and format the file:
+//  set.AddFormatter(gounexport.TidyFile)
+package edit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dooman87/gounexport/util"
+)
+
+//Edit is a replacement of From string at the Offset in the File
+//by To string.
+type Edit struct {
+	//Full path to the file
+	File string
+	//Offset in bytes where From string is started
+	Offset int
+	//Original string
+	From string
+	//String to replace
+	To string
+}
+
+//Formatter returns new content of the file after edits were
+//applied. Original is a content of the file before edits.
+type Formatter func(file string, original []byte, changed []byte) ([]byte, error)
+
+//Set is a collection of edits across several files.
+type Set struct {
+	edits      map[string][]*Edit
+	formatters []Formatter
+	//originals contains content of files before Commit
+	originals map[string][]byte
+}
+
+//writeFile is used to write files on Commit and Rollback.
+//It's a variable to simulate failures in tests.
+var writeFile = atomicWriteFile
+
+//NewSet creates empty set of edits
+func NewSet() *Set {
+	set := new(Set)
+	set.edits = make(map[string][]*Edit)
+	return set
+}
+
+//Add adds a new edit to the set. It doesn't change the file.
+func (set *Set) Add(file string, offset int, from string, to string) error {
+	e := new(Edit)
+	e.File = file
+	e.Offset = offset
+	e.From = from
+	e.To = to
+	set.edits[file] = append(set.edits[file], e)
+	util.Debug("edit [%s] at %d from [%s] to [%s]", file, offset, from, to)
+	return nil
+}
+
+//AddFormatter adds formatter that is called for each changed
+//file in the order they were added.
+func (set *Set) AddFormatter(formatter Formatter) {
+	set.formatters = append(set.formatters, formatter)
+}
+
+//Files returns sorted list of files that are changed by the set
+func (set *Set) Files() []string {
+	files := make([]string, 0, len(set.edits))
+	for f := range set.edits {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+//Apply reads all files and applies edits to their content.
+//Files are not changed. Returns map where key is a file name
+//and value is a new content of the file.
+func (set *Set) Apply() (map[string][]byte, error) {
+	_, changed, err := set.apply()
+	return changed, err
+}
+
+func (set *Set) apply() (map[string][]byte, map[string][]byte, error) {
+	originals := make(map[string][]byte, len(set.edits))
+	changed := make(map[string][]byte, len(set.edits))
+	for _, file := range set.Files() {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		edits, err := prepareEdits(content, set.edits[file])
+		if err != nil {
+			return nil, nil, err
+		}
+		originals[file] = content
+		changed[file] = applyEdits(content, edits)
+		for _, formatter := range set.formatters {
+			if changed[file], err = formatter(file, content, changed[file]); err != nil {
+				return nil, nil, fmt.Errorf("%s: %v", file, err)
+			}
+		}
+	}
+	return originals, changed, nil
+}
+
+//Commit applies all edits and writes files. Each file is written to
+//a temporary file first and then renamed to the original one. If any
+//file can't be written, then all already written files are restored,
+//so either all files are changed or nothing.
+func (set *Set) Commit() error {
+	originals, changed, err := set.apply()
+	if err != nil {
+		return err
+	}
+
+	var written []string
+	for _, file := range set.Files() {
+		if err := writeFile(file, changed[file]); err != nil {
+			util.Err("error while writing [%s], restoring %d changed files: %v", file, len(written), err)
+			if restoreErr := restore(written, originals); restoreErr != nil {
+				return fmt.Errorf("%v, restore failed: %v", err, restoreErr)
+			}
+			return err
+		}
+		written = append(written, file)
+	}
+	set.originals = originals
+	return nil
+}
+
+//Rollback restores content of files that were changed by Commit.
+func (set *Set) Rollback() error {
+	if set.originals == nil {
+		return fmt.Errorf("edits were not committed")
+	}
+	if err := restore(set.Files(), set.originals); err != nil {
+		return err
+	}
+	set.originals = nil
+	return nil
+}
+
+//restore writes original content to files. It's trying to
+//restore all files even if some of them are failed.
+func restore(files []string, originals map[string][]byte) error {
+	var failed []string
+	for _, file := range files {
+		if err := writeFile(file, originals[file]); err != nil {
+			util.Err("error while restoring [%s]: %v", file, err)
+			failed = append(failed, file)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("can't restore files %v", failed)
+	}
+	return nil
+}
+
+//atomicWriteFile writes content to the temporary file in the same
+//directory and renames it to file, so file is never half-written.
+func atomicWriteFile(file string, content []byte) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+
+	temp, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".gounexport-")
+	if err != nil {
+		return err
+	}
+	_, err = temp.Write(content)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp.Name(), info.Mode())
+	}
+	if err == nil {
+		err = os.Rename(temp.Name(), file)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+	}
+	return err
+}
+
+//Diff returns unified diff of all changes in the set that could be
+//applied by patch or git apply. Pathes are relative to the working
+//directory if files are inside it.
+func (set *Set) Diff() (string, error) {
+	originals, changed, err := set.apply()
+	if err != nil {
+		return "", err
+	}
+
+	result := ""
+	for _, file := range set.Files() {
+		result += Diff(relativePath(file), originals[file], changed[file])
+	}
+	return result, nil
+}
+
+//prepareEdits returns edits of one file sorted by offset in descending
+//order without duplicates. It fails if original text at the offset
+//doesn't match From string or if edits are overlapped, cause
+//there is no way to apply them safely.
+func prepareEdits(content []byte, edits []*Edit) ([]*Edit, error) {
+	sorted := make([]*Edit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Offset > sorted[j].Offset
+	})
+
+	var result []*Edit
+	for _, e := range sorted {
+		if e.Offset < 0 || e.Offset+len(e.From) > len(content) {
+			return nil, fmt.Errorf("%s: offset %d of [%s] is out of file", e.File, e.Offset, e.From)
+		}
+		if actual := string(content[e.Offset : e.Offset+len(e.From)]); actual != e.From {
+			return nil, fmt.Errorf("%s: expected [%s] at offset %d, but found [%s]", e.File, e.From, e.Offset, actual)
+		}
+
+		if len(result) > 0 {
+			prev := result[len(result)-1]
+			if *prev == *e {
+				util.Debug("skipping duplicate edit [%s] at %d", e.From, e.Offset)
+				continue
+			}
+			if e.Offset+len(e.From) > prev.Offset || e.Offset == prev.Offset {
+				return nil, fmt.Errorf("%s: edit [%s] -> [%s] at offset %d overlaps with edit [%s] -> [%s] at offset %d",
+					e.File, e.From, e.To, e.Offset, prev.From, prev.To, prev.Offset)
+			}
+		}
+		result = append(result, e)
+	}
+	return result, nil
+}
+
+//applyEdits replaces strings in content. Edits should be
+//sorted by offset in descending order, so each replacement
+//doesn't shift offsets of the next ones.
+func applyEdits(content []byte, edits []*Edit) []byte {
+	for _, e := range edits {
+		changed := make([]byte, 0, len(content)-len(e.From)+len(e.To))
+		changed = append(changed, content[0:e.Offset]...)
+		changed = append(changed, e.To...)
+		changed = append(changed, content[e.Offset+len(e.From):]...)
+		content = changed
+	}
+	return content
+}
+
+func relativePath(file string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return file
+	}
+	rel, err := filepath.Rel(wd, file)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,301 @@
+package edit
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	original = `package foo
+
+//Foo is foo
+func Foo() {
+	Bar()
+}
+
+func a() {}
+
+func b() {}
+
+func c() {}
+
+func d() {}
+
+func Bar() {}
+`
+)
+
+var (
+	fooOffset = strings.Index(original, "Foo()")
+	barOffset = strings.Index(original, "Bar()")
+)
+
+func writeTempFile(t *testing.T) string {
+	file := filepath.Join(t.TempDir(), "foo.go")
+	if err := ioutil.WriteFile(file, []byte(original), 0644); err != nil {
+		t.Fatalf("%v", err)
+	}
+	return file
+}
+
+func TestSetApply(t *testing.T) {
+	file := writeTempFile(t)
+	set := NewSet()
+	set.Add(file, fooOffset, "Foo", "foo")
+	set.Add(file, barOffset, "Bar", "bar")
+
+	changed, err := set.Apply()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := original[0:fooOffset] + "foo" + original[fooOffset+3:barOffset] + "bar" + original[barOffset+3:]
+	if string(changed[file]) != expected {
+		t.Errorf("expected \n[%s], but found\n[%s]", expected, string(changed[file]))
+	}
+
+	content, _ := ioutil.ReadFile(file)
+	if string(content) != original {
+		t.Errorf("file should not be changed, but found\n[%s]", string(content))
+	}
+}
+
+func TestDiff(t *testing.T) {
+	changed := original[0:fooOffset] + "foo" + original[fooOffset+3:barOffset] + "bar" + original[barOffset+3:len(original)-9] + "bar() {}\n"
+	expected := `--- a/foo.go
++++ b/foo.go
+@@ -1,8 +1,8 @@
+ package foo
+ 
+ //Foo is foo
+-func Foo() {
+-	Bar()
++func foo() {
++	bar()
+ }
+ 
+ func a() {}
+@@ -13,4 +13,4 @@
+ 
+ func d() {}
+ 
+-func Bar() {}
++func bar() {}
+`
+	diff := Diff("foo.go", []byte(original), []byte(changed))
+	if diff != expected {
+		t.Errorf("expected \n[%s], but found\n[%s]", expected, diff)
+	}
+}
+
+func TestDiffNoChanges(t *testing.T) {
+	if diff := Diff("foo.go", []byte(original), []byte(original)); len(diff) > 0 {
+		t.Errorf("expected empty diff, but found\n[%s]", diff)
+	}
+}
+
+func TestDiffNoNewLine(t *testing.T) {
+	expected := `--- a/foo.txt
++++ b/foo.txt
+@@ -1,2 +1,2 @@
+ first
+-Second
+\ No newline at end of file
++second
+\ No newline at end of file
+`
+	diff := Diff("foo.txt", []byte("first\nSecond"), []byte("first\nsecond"))
+	if diff != expected {
+		t.Errorf("expected \n[%s], but found\n[%s]", expected, diff)
+	}
+}
+
+func TestDiffLargeFile(t *testing.T) {
+	//Changes at the start and the end of the file are
+	//not trimmed as common prefix and suffix
+	var a, b strings.Builder
+	lines := 200000
+	for i := 0; i < lines; i++ {
+		line := fmt.Sprintf("func f%d() {}\n", i)
+		a.WriteString(line)
+		if i == 10 || i == lines-10 {
+			line = fmt.Sprintf("func g%d() {}\n", i)
+		}
+		b.WriteString(line)
+	}
+
+	diff := Diff("large.go", []byte(a.String()), []byte(b.String()))
+	if hunks := strings.Count(diff, "@@ -"); hunks != 2 {
+		t.Errorf("expected 2 hunks, but found %d in\n[%s]", hunks, diff)
+	}
+	expected := fmt.Sprintf("@@ -%d,7 +%d,7 @@\n", lines-12, lines-12)
+	if !strings.Contains(diff, expected) || !strings.Contains(diff, "+func g199990() {}\n") {
+		t.Errorf("expected hunk [%s] at the end of file, but found\n[%s]", expected, diff)
+	}
+}
+
+func TestSetDiff(t *testing.T) {
+	file := writeTempFile(t)
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(filepath.Dir(file))
+
+	set := NewSet()
+	set.Add(file, fooOffset, "Foo", "foo")
+	diff, err := set.Diff()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := `--- a/foo.go
++++ b/foo.go
+@@ -1,7 +1,7 @@
+ package foo
+ 
+ //Foo is foo
+-func Foo() {
++func foo() {
+ 	Bar()
+ }
+ 
+`
+	if diff != expected {
+		t.Errorf("expected \n[%s], but found\n[%s]", expected, diff)
+	}
+}
+
+func TestSetCommit(t *testing.T) {
+	file := writeTempFile(t)
+	set := NewSet()
+	set.Add(file, fooOffset, "Foo", "foo")
+
+	if err := set.Commit(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	content, _ := ioutil.ReadFile(file)
+	expected := original[0:fooOffset] + "foo" + original[fooOffset+3:]
+	if string(content) != expected {
+		t.Errorf("expected \n[%s], but found\n[%s]", expected, string(content))
+	}
+
+	if err := set.Rollback(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	content, _ = ioutil.ReadFile(file)
+	if string(content) != original {
+		t.Errorf("expected original content after rollback, but found\n[%s]", string(content))
+	}
+}
+
+func TestSetCommitRestore(t *testing.T) {
+	first := writeTempFile(t)
+	second := writeTempFile(t)
+	set := NewSet()
+	set.Add(first, fooOffset, "Foo", "foo")
+	set.Add(second, fooOffset, "Foo", "foo")
+
+	defer func() { writeFile = atomicWriteFile }()
+	failed := false
+	writeFile = func(file string, content []byte) error {
+		if file == set.Files()[1] && !failed {
+			failed = true
+			return os.ErrPermission
+		}
+		return atomicWriteFile(file, content)
+	}
+
+	if err := set.Commit(); err == nil {
+		t.Fatal("expected error from commit")
+	}
+	for _, file := range []string{first, second} {
+		content, _ := ioutil.ReadFile(file)
+		if string(content) != original {
+			t.Errorf("expected original content of [%s], but found\n[%s]", file, string(content))
+		}
+	}
+	if err := set.Rollback(); err == nil {
+		t.Error("expected error from rollback of failed commit")
+	}
+}
+
+func TestSetCommitOutOfFile(t *testing.T) {
+	file := writeTempFile(t)
+	set := NewSet()
+	set.Add(file, len(original)-1, "Foo", "foo")
+
+	if err := set.Commit(); err == nil {
+		t.Error("expected error for edit out of file")
+	}
+}
+
+func TestSetApplyDifferentLength(t *testing.T) {
+	file := writeTempFile(t)
+	set := NewSet()
+	set.Add(file, fooOffset, "Foo", "renamedFoo")
+	set.Add(file, barOffset, "Bar", "b")
+	set.Add(file, fooOffset, "Foo", "renamedFoo")
+
+	changed, err := set.Apply()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := original[0:fooOffset] + "renamedFoo" + original[fooOffset+3:barOffset] + "b" + original[barOffset+3:]
+	if string(changed[file]) != expected {
+		t.Errorf("expected \n[%s], but found\n[%s]", expected, string(changed[file]))
+	}
+}
+
+func TestSetApplyMismatch(t *testing.T) {
+	file := writeTempFile(t)
+	set := NewSet()
+	set.Add(file, barOffset, "Foo", "foo")
+
+	if _, err := set.Apply(); err == nil || !strings.Contains(err.Error(), "expected [Foo]") {
+		t.Errorf("expected mismatch error, but found %v", err)
+	}
+}
+
+func TestSetApplyOverlap(t *testing.T) {
+	file := writeTempFile(t)
+	set := NewSet()
+	set.Add(file, fooOffset, "Foo", "foo")
+	set.Add(file, fooOffset, "Foo", "bar")
+	set.Add(file, barOffset, "Bar()", "bar()")
+	set.Add(file, barOffset+1, "ar", "AR")
+
+	_, err := set.Apply()
+	if err == nil || !strings.Contains(err.Error(), "overlaps") {
+		t.Errorf("expected overlap error, but found %v", err)
+	}
+
+	set = NewSet()
+	set.Add(file, barOffset, "Bar()", "bar()")
+	set.Add(file, barOffset+1, "ar", "AR")
+	_, err = set.Apply()
+	if err == nil || !strings.Contains(err.Error(), "overlaps") {
+		t.Errorf("expected overlap error, but found %v", err)
+	}
+}
+
+func TestSetApplyFormatter(t *testing.T) {
+	file := writeTempFile(t)
+	set := NewSet()
+	set.Add(file, fooOffset, "Foo", "foo")
+	set.AddFormatter(func(f string, before []byte, changed []byte) ([]byte, error) {
+		if string(before) != original {
+			t.Errorf("expected original content in formatter")
+		}
+		return []byte(strings.ToUpper(string(changed))), nil
+	})
+
+	changed, err := set.Apply()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := strings.ToUpper(original[0:fooOffset] + "foo" + original[fooOffset+3:])
+	if string(changed[file]) != expected {
+		t.Errorf("expected \n[%s], but found\n[%s]", expected, string(changed[file]))
+	}
+}
@@ -0,0 +1,86 @@
+package gounexport
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+
+	"github.com/dooman87/gounexport/fs"
+)
+
+//TypeErrors is a list of type errors that were found while checking
+//packages. It's returned as error by ParsePackage together with
+//partial results, so caller could decide to continue analysis or not.
+//For example,
+//  pkg, fset, err := gounexport.ParsePackage(pkgName, info)
+//  if typeErrors, ok := err.(gounexport.TypeErrors); ok {
+//  	log.Printf("%d files failed to type check", len(typeErrors.Files()))
+//  } else if err != nil {
+//  	return err
+//  }
+type TypeErrors []types.Error
+
+//Error returns the first error and number of other errors
+func (errs TypeErrors) Error() string {
+	switch len(errs) {
+	case 0:
+		return "no type errors"
+	case 1:
+		return errs[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", errs[0], len(errs)-1)
+}
+
+//Err returns nil if list is empty or list itself otherwise
+func (errs TypeErrors) Err() error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+//Files returns sorted list of files that have type errors
+func (errs TypeErrors) Files() []string {
+	files := make(map[string]bool)
+	for _, e := range errs {
+		if e.Fset != nil {
+			files[e.Fset.Position(e.Pos).Filename] = true
+		}
+	}
+	return sortedKeys(files)
+}
+
+//Packages returns sorted list of packages that have type errors
+func (errs TypeErrors) Packages() []string {
+	packages := make(map[string]bool)
+	for _, f := range errs.Files() {
+		packages[fs.GetPackagePath(f)] = true
+	}
+	return sortedKeys(packages)
+}
+
+//appendTypeErrors adds errors from src to dst that are not
+//presented in dst yet. The same errors are found when package is
+//checked for several build contexts.
+func appendTypeErrors(dst TypeErrors, src []types.Error) TypeErrors {
+	existing := make(map[string]bool, len(dst))
+	for _, e := range dst {
+		existing[typeErrorPosition(e)+e.Msg] = true
+	}
+	for _, e := range src {
+		if key := typeErrorPosition(e) + e.Msg; !existing[key] {
+			existing[key] = true
+			dst = append(dst, e)
+		}
+	}
+	return dst
+}
+
+func sortedKeys(m map[string]bool) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,72 @@
+package gounexport_test
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dooman87/gounexport"
+	"github.com/dooman87/gounexport/fs"
+)
+
+const typeErrorsSource = `package typeerrors
+
+func Broken() int {
+	return Unknown()
+}
+
+func Unused() int {
+	return 1
+}
+`
+
+func TestGetDefinitionsTypeErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gounexport-typeerrors")
+	if err != nil {
+		t.Fatalf("error while creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "typeerrors.go")
+	writeTestFile(filepath.Join(dir, "go.mod"), "module example.com/typeerrors\n", t)
+	writeTestFile(file, typeErrorsSource, t)
+	if _, err := fs.FindModule(dir); err != nil {
+		t.Fatalf("error while reading module %v", err)
+	}
+
+	pkg := "example.com/typeerrors"
+	linux := build.Default
+	linux.GOOS = "linux"
+	windows := build.Default
+	windows.GOOS = "windows"
+	defs, err := gounexport.GetDefinitionsWithContexts(pkg, []*build.Context{&linux, &windows})
+	typeErrors, ok := err.(gounexport.TypeErrors)
+	if !ok {
+		t.Fatalf("expected type errors, but found %v", err)
+	}
+
+	//The same error is found in both contexts
+	if len(typeErrors) != 1 {
+		t.Errorf("expected 1 type error, but found %v", typeErrors)
+	}
+	if files := typeErrors.Files(); len(files) != 1 || files[0] != file {
+		t.Errorf("expected [%s] file with errors, but found %v", file, files)
+	}
+	if packages := typeErrors.Packages(); len(packages) != 1 || packages[0] != pkg {
+		t.Errorf("expected [%s] package with errors, but found %v", pkg, packages)
+	}
+
+	//Partial results are returned
+	if gounexport.FindDefinitionByName(defs, pkg+".Unused") == nil {
+		t.Errorf("expected definition %s.Unused", pkg)
+	}
+}
+
+func TestTypeErrorsErr(t *testing.T) {
+	var typeErrors gounexport.TypeErrors
+	if err := typeErrors.Err(); err != nil {
+		t.Errorf("expected nil error for empty list, but found %v", err)
+	}
+}
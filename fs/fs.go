@@ -1,30 +1,33 @@
 //Package fs provides utility functions to work with sources files
-//relate to standard golang workspace. It's using GOROOT and GOHOME
-//environment variables to search.
+//relate to go modules and standard golang workspace. It's using go.mod
+//files, GOROOT, GOPATH and GOMODCACHE environment variables to search.
 package fs
 
 import (
 	"go/token"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/dooman87/gounexport/util"
 )
 
-//GetFiles returns all golang source files which is inside a package.
-//A path to the package is constructing using standard workspace
-//layout - $GOPATH/src.
-//All tests files are ignored.
+//SourceFiles returns all golang source files which is inside a package.
+//A path to the package is resolving by PackageDir, so
+//both go modules and standard workspace layout - $GOPATH/src
+//are supported.
 //If deep flag is true then will return
-//files from all subpackages as well.
+//files from all subpackages as well. Subdirectories with
+//own go.mod are skipped because they are separate modules.
 //It collects only files that name ends with .go extension.
 //Returns list of full file names.
 func SourceFiles(pkg string, deep bool) ([]string, error) {
-	result, err := getSourceFiles(os.Getenv("GOPATH")+"/src/"+pkg, deep)
+	pkgPath, err := PackageDir(pkg)
 	if err != nil {
-		result, err = getSourceFiles(os.Getenv("GOROOT")+"/src/"+pkg, deep)
+		util.Err("error while resolving package [%s]\n%v", pkg, err)
+		return nil, err
 	}
-	return result, err
+	return getSourceFiles(pkgPath, deep)
 }
 
 func getSourceFiles(pkgPath string, deep bool) ([]string, error) {
@@ -42,7 +45,7 @@ func getSourceFiles(pkgPath string, deep bool) ([]string, error) {
 
 	var files []string
 	for _, f := range filesInfos {
-		if f.IsDir() && deep && isValidSourceDir(f.Name()) {
+		if f.IsDir() && deep && isValidSourceDir(pkgPath+"/"+f.Name()) {
 			util.Debug("append folder [%s]", f.Name())
 			dirFiles, err := getSourceFiles(pkgPath+"/"+f.Name(), true)
 			if err != nil {
@@ -69,7 +72,14 @@ func isValidSourceFile(file string) bool {
 }
 
 func isValidSourceDir(dir string) bool {
-	return !strings.HasPrefix(dir, ".")
+	if strings.HasPrefix(filepath.Base(dir), ".") {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+		util.Debug("skipping nested module [%s]", dir)
+		return false
+	}
+	return true
 }
 
 //GetUnusedSources returns list of source files in package that
@@ -92,8 +102,10 @@ func GetUnusedSources(pkg string, fset *token.FileSet) ([]string, error) {
 	return unusedSource, nil
 }
 
-//GetPackagePath return relative path
-//to standard go workspace layout - GOPATH/src
+//GetPackagePath returns import path of the package
+//where the directory or the file is located. It's relative path
+//to the module root (prefixed with module path), to the
+//module cache or to standard go workspace layout - GOPATH/src
 // and GOROOT/src
 //and trims file name if it presents.
 func GetPackagePath(dirPath string) string {
@@ -104,15 +116,20 @@ func GetPackagePath(dirPath string) string {
 	return result
 }
 
-//GetRelativePath returns relative path
+//GetRelativePath returns path relative to the module
+//root prefixed with module path or relative
 //to $GOPATH or $GOROOT env variable.
 func GetRelativePath(path string) string {
-	prefix := os.Getenv("GOPATH") + "/src/"
+	if result, ok := modulePackagePath(path); ok {
+		return result
+	}
+
+	prefix := gopath() + "/src/"
 	result := path
 	if strings.HasPrefix(path, prefix) {
 		result = path[len(prefix):]
 	}
-	prefix = os.Getenv("GOROOT") + "/src/"
+	prefix = goroot() + "/src/"
 	if strings.HasPrefix(path, prefix) {
 		result = path[len(prefix):]
 	}
@@ -126,6 +143,9 @@ func GetRelativePath(path string) string {
 // * Truncate file from the offset
 // * Append new string
 // * Append rest of the file that was stored on the first step
+//Offsets of the next replacements in the same file are shifted if
+//lengths of from and to are different. Use edit.Set to apply several
+//replacements safely.
 func ReplaceStringInFile(file string, offset int, from string, to string) error {
 	sourceFile, err := os.OpenFile(file, os.O_RDWR, 0)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"go/token"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
@@ -13,8 +14,17 @@ const (
 	pkg = "github.com/dooman87/gounexport/testdata"
 )
 
+//testdataDir returns absolute path to testdata of the repository
+func testdataDir(t *testing.T) string {
+	dir, err := filepath.Abs("../testdata")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return dir
+}
+
 func TestSourceFiles(t *testing.T) {
-	basepath := os.Getenv("GOPATH") + "/src/" + pkg
+	basepath := testdataDir(t)
 	expected := []string{
 		basepath + "/testfunc/main/main.go",
 		basepath + "/testfunc/func.go",
@@ -64,7 +74,7 @@ func TestGetUnusedSources(t *testing.T) {
 
 func TestGetPackagePathDir(t *testing.T) {
 	expected := "github.com/dooman87/gounexport/testdata/testfunc/main"
-	packagePath := GetPackagePath(os.Getenv("GOPATH") + "/src/github.com/dooman87/gounexport/testdata/testfunc/main")
+	packagePath := GetPackagePath(testdataDir(t) + "/testfunc/main")
 	if packagePath != expected {
 		t.Errorf("expected [%s] package path but get [%s]", expected, packagePath)
 	}
@@ -72,7 +82,7 @@ func TestGetPackagePathDir(t *testing.T) {
 
 func TestGetPackagePathFile(t *testing.T) {
 	expected := "github.com/dooman87/gounexport/testdata/testfunc/main"
-	packagePath := GetPackagePath(os.Getenv("GOPATH") + "/src/github.com/dooman87/gounexport/testdata/testfunc/main/file.go")
+	packagePath := GetPackagePath(testdataDir(t) + "/testfunc/main/file.go")
 	if packagePath != expected {
 		t.Errorf("expected [%s] package path but get [%s]", expected, packagePath)
 	}
@@ -89,7 +99,7 @@ func TestReplaceStringInFile(t *testing.T) {
    replace the first
 	 Replace word
 }`
-	file := os.Getenv("GOPATH") + "/src/github.com/dooman87/gounexport/testdata/testreplace.txt"
+	file := testdataDir(t) + "/testreplace.txt"
 	ioutil.WriteFile(file, []byte(original), 0)
 	ReplaceStringInFile(file, 16, "Replace", "replace")
 	content, _ := ioutil.ReadFile(file)
@@ -98,3 +108,179 @@ func TestReplaceStringInFile(t *testing.T) {
 		t.Errorf("expected \n[%s], but found\n[%s]", expected, strContent)
 	}
 }
+
+func TestFindModule(t *testing.T) {
+	moduleDir := testdataDir(t) + "/testmodule"
+	m, err := FindModule(moduleDir + "/main")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if m == nil {
+		t.Fatalf("expected module in [%s]", moduleDir)
+	}
+	if m.Path != "example.com/testmodule" {
+		t.Errorf("expected [example.com/testmodule] module path, but found [%s]", m.Path)
+	}
+	if m.Dir != moduleDir {
+		t.Errorf("expected [%s] module dir, but found [%s]", moduleDir, m.Dir)
+	}
+	if m.Requires["github.com/BurntSushi/toml"] != "v0.3.1" {
+		t.Errorf("expected [v0.3.1] version of toml, but found [%s]", m.Requires["github.com/BurntSushi/toml"])
+	}
+	if r := m.Replaces["example.com/dep"]; r == nil || r.Path != "./dep" {
+		t.Errorf("expected [./dep] replacement of example.com/dep, but found [%v]", r)
+	}
+}
+
+func TestListModulesReadonly(t *testing.T) {
+	moduleDir := testdataDir(t) + "/testmodule"
+	goMod, err := ioutil.ReadFile(moduleDir + "/go.mod")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	//-mod=mod allows go list to write go.sum
+	goflags := os.Getenv("GOFLAGS")
+	os.Setenv("GOFLAGS", "-mod=mod")
+	defer os.Setenv("GOFLAGS", goflags)
+
+	modules, err := listModules(moduleDir)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if modules["example.com/dep"] != moduleDir+"/dep" {
+		t.Errorf("expected [%s] dir of example.com/dep, but found [%s]", moduleDir+"/dep", modules["example.com/dep"])
+	}
+	if _, err := os.Stat(moduleDir + "/go.sum"); err == nil {
+		os.Remove(moduleDir + "/go.sum")
+		t.Errorf("expected go.sum to be not created")
+	}
+	if content, _ := ioutil.ReadFile(moduleDir + "/go.mod"); string(content) != string(goMod) {
+		ioutil.WriteFile(moduleDir+"/go.mod", goMod, 0644)
+		t.Errorf("expected go.mod to be not changed, but found\n%s", content)
+	}
+}
+
+func TestFindModuleGopath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopath")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, err := FindModule(dir)
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if m != nil {
+		t.Errorf("expected no module for GOPATH package, but found [%s]", m.Path)
+	}
+}
+
+func TestSourceFilesModule(t *testing.T) {
+	moduleDir := testdataDir(t) + "/testmodule"
+	if _, err := FindModule(moduleDir); err != nil {
+		t.Fatalf("%v", err)
+	}
+	expected := []string{
+		moduleDir + "/main/main.go",
+		moduleDir + "/testmodule.go",
+	}
+
+	files, err := SourceFiles("example.com/testmodule", true)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	sort.Strings(files)
+	if strings.Join(files, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v files, but found %v", expected, files)
+	}
+
+	files, err = SourceFiles("example.com/dep", false)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(files) != 1 || files[0] != moduleDir+"/dep/dep.go" {
+		t.Errorf("expected replaced dependency files, but found %v", files)
+	}
+}
+
+func TestGetPackagePathModule(t *testing.T) {
+	expected := "example.com/testmodule/main"
+	packagePath := GetPackagePath(testdataDir(t) + "/testmodule/main/main.go")
+	if packagePath != expected {
+		t.Errorf("expected [%s] package path but get [%s]", expected, packagePath)
+	}
+}
+
+func TestGetPackagePathModuleCache(t *testing.T) {
+	expected := "github.com/BurntSushi/toml/internal"
+	packagePath := GetPackagePath(moduleCache() + "/github.com/!burnt!sushi/toml@v0.3.1/internal/tz.go")
+	if packagePath != expected {
+		t.Errorf("expected [%s] package path but get [%s]", expected, packagePath)
+	}
+	if dir := moduleCacheDir("github.com/BurntSushi/toml", "v0.3.1"); dir != moduleCache()+"/github.com/!burnt!sushi/toml@v0.3.1" {
+		t.Errorf("unexpected module cache dir [%s]", dir)
+	}
+}
+
+func TestPackageDirSameModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "modules")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	//Both modules are replacing example.com/same, but
+	//only the second one has the directory
+	for _, name := range []string{"a", "b"} {
+		os.MkdirAll(filepath.Join(dir, name), os.ModePerm)
+		goMod := "module example.com/" + name + "\n\nreplace example.com/same => ./same\n"
+		ioutil.WriteFile(filepath.Join(dir, name, "go.mod"), []byte(goMod), os.ModePerm)
+		if _, err := FindModule(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	expected := filepath.Join(dir, "b", "same", "pkg")
+	os.MkdirAll(expected, os.ModePerm)
+
+	for i := 0; i < 10; i++ {
+		pkgDir, err := PackageDir("example.com/same/pkg")
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if pkgDir != expected {
+			t.Fatalf("expected [%s], but found [%s]", expected, pkgDir)
+		}
+	}
+}
+
+func TestPackageDirVendor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vendor")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	expected := filepath.Join(dir, "vendor", "example.com", "vendored", "pkg")
+	os.MkdirAll(expected, os.ModePerm)
+	ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/withvendor\n"), os.ModePerm)
+	ioutil.WriteFile(filepath.Join(dir, "vendor", "modules.txt"), []byte("# example.com/vendored v1.0.0\n"), os.ModePerm)
+	if _, err := FindModule(dir); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	pkgDir, err := PackageDir("example.com/vendored/pkg")
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if pkgDir != expected {
+		t.Errorf("expected [%s], but found [%s]", expected, pkgDir)
+	}
+}
+
+func TestPackageDirNotFound(t *testing.T) {
+	_, err := PackageDir("example.com/missing/pkg")
+	if err == nil || !strings.Contains(err.Error(), "can't find package [example.com/missing/pkg]") {
+		t.Errorf("expected error for missing package, but found %v", err)
+	}
+}
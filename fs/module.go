@@ -0,0 +1,519 @@
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dooman87/gounexport/util"
+)
+
+//Module describes go module that is declared by go.mod file.
+type Module struct {
+	//Path is a module path from the module directive
+	Path string
+	//Dir is a full path to the module root where go.mod is located
+	Dir string
+	//Requires maps required module pathes to their versions
+	Requires map[string]string
+	//Replaces maps module pathes to their replacements
+	Replaces map[string]*Replacement
+
+	//buildList maps pathes of all modules that are needed to build
+	//the module to their directories, see listModules
+	buildList map[string]string
+}
+
+//Replacement is a target of replace directive. If Version is empty
+//then Path is a local directory relative to the module root.
+type Replacement struct {
+	//Path is a module path or a local directory
+	Path string
+	//Version of replacement module. Empty for local directories.
+	Version string
+}
+
+var (
+	//modules contains all modules that were found so far,
+	//the key is a module root directory.
+	modules = make(map[string]*Module)
+	//moduleDirs caches results of go.mod lookups by directory.
+	moduleDirs = make(map[string]*Module)
+	//mainModuleLoaded is true when we already tried to
+	//find module in the working directory
+	mainModuleLoaded = false
+	//mainModule is a module of the working directory
+	mainModule *Module
+	//goModCache is a module cache directory from go env
+	goModCache string
+)
+
+//FindModule searches go.mod in the dir and all parent directories.
+//Found module will be used later to resolve packages that are belongs
+//to it, its requirements and replacements.
+//Returns nil if dir is not a part of any module.
+func FindModule(dir string) (*Module, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var visited []string
+	var result *Module
+	for {
+		if m, ok := moduleDirs[dir]; ok {
+			result = m
+			break
+		}
+		visited = append(visited, dir)
+
+		modFile := filepath.Join(dir, "go.mod")
+		if _, err := os.Stat(modFile); err == nil {
+			if result, err = parseModFile(modFile); err != nil {
+				return nil, err
+			}
+			modules[result.Dir] = result
+			util.Debug("found module [%s] at [%s]", result.Path, result.Dir)
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	for _, d := range visited {
+		moduleDirs[d] = result
+	}
+	return result, nil
+}
+
+//parseModFile reads go.mod file and returns module declared in it.
+//Only module, require and replace directives are supported.
+func parseModFile(file string) (*Module, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	m := new(Module)
+	m.Dir = filepath.Dir(file)
+	m.Requires = make(map[string]string)
+	m.Replaces = make(map[string]*Replacement)
+
+	block := ""
+	for i, line := range strings.Split(string(content), "\n") {
+		if idx := strings.Index(line, "//"); idx >= 0 {
+			line = line[0:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(block) > 0 {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			fields = append([]string{block}, fields...)
+		} else if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+
+		if err := m.addDirective(fields); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", file, i+1, err)
+		}
+	}
+
+	if len(m.Path) == 0 {
+		return nil, fmt.Errorf("%s: module directive is missing", file)
+	}
+	return m, nil
+}
+
+func (m *Module) addDirective(fields []string) error {
+	for i := range fields {
+		if unquoted, err := strconv.Unquote(fields[i]); err == nil {
+			fields[i] = unquoted
+		}
+	}
+
+	switch fields[0] {
+	case "module":
+		if len(fields) != 2 {
+			return fmt.Errorf("invalid module directive")
+		}
+		m.Path = fields[1]
+	case "require":
+		if len(fields) != 3 {
+			return fmt.Errorf("invalid require directive")
+		}
+		m.Requires[fields[1]] = fields[2]
+	case "replace":
+		//replace old [version] => new [version]
+		arrowIdx := indexOf(fields, "=>")
+		if arrowIdx < 2 || arrowIdx > 3 || len(fields)-arrowIdx < 2 || len(fields)-arrowIdx > 3 {
+			return fmt.Errorf("invalid replace directive")
+		}
+		r := new(Replacement)
+		r.Path = fields[arrowIdx+1]
+		if len(fields)-arrowIdx == 3 {
+			r.Version = fields[arrowIdx+2]
+		}
+		m.Replaces[fields[1]] = r
+	}
+	return nil
+}
+
+//PackageDir returns directory of the package. It's looking for
+//the package in the next order:
+// * known modules (see FindModule), their vendor directories and replace directives
+// * requirements of the known modules in the module cache
+// * $GOPATH/src
+// * $GOROOT/src
+// * modules from the build list of the known modules (go list -m all),
+//   that is used for indirect dependencies that are missing in go.mod
+func PackageDir(pkg string) (string, error) {
+	loadMainModule()
+
+	var candidates []string
+	if dir, ok := moduleDir(pkg); ok {
+		candidates = append(candidates, dir)
+	}
+	candidates = append(candidates,
+		filepath.Join(gopath(), "src", pkg),
+		filepath.Join(goroot(), "src", pkg))
+
+	for _, dir := range candidates {
+		if isDir(dir) {
+			return dir, nil
+		}
+	}
+	if dir, ok := buildListDir(pkg); ok {
+		return dir, nil
+	}
+	return "", fmt.Errorf("can't find package [%s] in modules %v, GOPATH or GOROOT", pkg, modulePaths())
+}
+
+//moduleCandidate is a directory of the package in a module
+type moduleCandidate struct {
+	modulePath string
+	dir        string
+}
+
+//moduleDir resolves package using known modules. The longest
+//matching module path wins. If the same module is required by
+//several modules (e.g. with different versions), then the first
+//existing directory is used. The main module goes first, then
+//modules in order of their directories, so the result doesn't
+//depend on the order of maps.
+func moduleDir(pkg string) (string, bool) {
+	var candidates []*moduleCandidate
+	add := func(modulePath string, dir func() string) {
+		if hasPathPrefix(pkg, modulePath) {
+			c := new(moduleCandidate)
+			c.modulePath = modulePath
+			c.dir = filepath.Join(dir(), filepath.FromSlash(pkg[len(modulePath):]))
+			candidates = append(candidates, c)
+		}
+	}
+
+	for _, module := range sortedModules() {
+		m := module
+		add(m.Path, func() string { return m.Dir })
+		//Vendored packages are stored by full import paths
+		if isFile(filepath.Join(m.Dir, "vendor", "modules.txt")) {
+			add(pkg, func() string { return filepath.Join(m.Dir, "vendor", filepath.FromSlash(pkg)) })
+		}
+		for _, path := range sortedKeys(m.Replaces) {
+			r := m.Replaces[path]
+			add(path, func() string { return m.replacementDir(r) })
+		}
+		for _, path := range sortedKeys(m.Requires) {
+			//Replacements are overriding requirements
+			if _, ok := m.Replaces[path]; ok {
+				continue
+			}
+			modulePath, version := path, m.Requires[path]
+			add(modulePath, func() string { return moduleCacheDir(modulePath, version) })
+		}
+	}
+	if len(candidates) == 0 {
+		return "", false
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return len(candidates[i].modulePath) > len(candidates[j].modulePath)
+	})
+	for _, c := range candidates {
+		if isDir(c.dir) {
+			return c.dir, true
+		}
+	}
+	return candidates[0].dir, true
+}
+
+//sortedModules returns known modules. The main module is the first,
+//others are sorted by their directories.
+func sortedModules() []*Module {
+	var result []*Module
+	for _, m := range modules {
+		result = append(result, m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if (result[i] == mainModule) != (result[j] == mainModule) {
+			return result[i] == mainModule
+		}
+		return result[i].Dir < result[j].Dir
+	})
+	return result
+}
+
+func modulePaths() []string {
+	var result []string
+	for _, m := range sortedModules() {
+		result = append(result, m.Path)
+	}
+	return result
+}
+
+func sortedKeys(values interface{}) []string {
+	var keys []string
+	switch values := values.(type) {
+	case map[string]string:
+		for key := range values {
+			keys = append(keys, key)
+		}
+	case map[string]*Replacement:
+		for key := range values {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//buildListDir resolves package using build lists of the known modules.
+//go.mod doesn't contain all indirect dependencies, so the go command
+//is used to find them. Build lists are loaded once for each module.
+func buildListDir(pkg string) (string, bool) {
+	for _, m := range sortedModules() {
+		if m.buildList == nil {
+			var err error
+			if m.buildList, err = listModules(m.Dir); err != nil {
+				util.Warn("can't list modules of [%s]: %v", m.Path, err)
+				m.buildList = make(map[string]string)
+			}
+		}
+		matchedPath := ""
+		for modulePath := range m.buildList {
+			if hasPathPrefix(pkg, modulePath) && len(modulePath) > len(matchedPath) {
+				matchedPath = modulePath
+			}
+		}
+		if len(matchedPath) == 0 {
+			continue
+		}
+		dir := filepath.Join(m.buildList[matchedPath], filepath.FromSlash(pkg[len(matchedPath):]))
+		if isDir(dir) {
+			return dir, true
+		}
+	}
+	return "", false
+}
+
+//listModules runs go list -m -json all in the module directory
+//and returns directories of modules by their paths. Modules that
+//are not downloaded to the module cache are skipped. go.mod and
+//go.sum are not changed (-mod=readonly overrides GOFLAGS), and
+//modules with missing sums are listed without directories (-e).
+func listModules(dir string) (map[string]string, error) {
+	cmd := exec.Command("go", "list", "-mod=readonly", "-e", "-m", "-json", "all")
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	type listedModule struct {
+		Path    string
+		Dir     string
+		Replace *listedModule
+	}
+	result := make(map[string]string)
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	for decoder.More() {
+		m := new(listedModule)
+		if err := decoder.Decode(m); err != nil {
+			return nil, err
+		}
+		moduleDir := m.Dir
+		if m.Replace != nil && len(m.Replace.Dir) > 0 {
+			moduleDir = m.Replace.Dir
+		}
+		if len(moduleDir) > 0 {
+			result[m.Path] = moduleDir
+		}
+	}
+	return result, nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+func (m *Module) replacementDir(r *Replacement) string {
+	if len(r.Version) > 0 {
+		return moduleCacheDir(r.Path, r.Version)
+	}
+	dir := filepath.FromSlash(r.Path)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(m.Dir, dir)
+	}
+	//Registering replacement to resolve its own requirements
+	if _, err := FindModule(dir); err != nil {
+		util.Warn("can't read module at [%s]: %v", dir, err)
+	}
+	return dir
+}
+
+//modulePackagePath returns import path for the directory if it
+//belongs to a known module or to the module cache.
+func modulePackagePath(dir string) (string, bool) {
+	if prefix := moduleCache() + string(filepath.Separator); strings.HasPrefix(dir, prefix) {
+		rel := filepath.ToSlash(dir[len(prefix):])
+		if strings.HasPrefix(rel, "cache/") {
+			return "", false
+		}
+		if atIdx := strings.Index(rel, "@"); atIdx >= 0 {
+			rest := ""
+			if slashIdx := strings.Index(rel[atIdx:], "/"); slashIdx >= 0 {
+				rest = rel[atIdx+slashIdx:]
+			}
+			return unescapeModulePath(rel[0:atIdx]) + rest, true
+		}
+	}
+
+	m, err := FindModule(dir)
+	if err != nil || m == nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(m.Dir, dir)
+	if err != nil {
+		return "", false
+	}
+	if rel == "." {
+		return m.Path, true
+	}
+	return m.Path + "/" + filepath.ToSlash(rel), true
+}
+
+//loadMainModule registers module from the working directory
+func loadMainModule() {
+	if mainModuleLoaded {
+		return
+	}
+	mainModuleLoaded = true
+	wd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	if mainModule, err = FindModule(wd); err != nil {
+		util.Warn("can't read module at [%s]: %v", wd, err)
+	}
+}
+
+func moduleCacheDir(path string, version string) string {
+	return filepath.Join(moduleCache(), filepath.FromSlash(escapeModulePath(path)+"@"+version))
+}
+
+//moduleCache returns GOMODCACHE. It could be set in the environment
+//or by go env -w, so go env is used if the variable is not set.
+func moduleCache() string {
+	if cache := os.Getenv("GOMODCACHE"); len(cache) > 0 {
+		return cache
+	}
+	if len(goModCache) == 0 {
+		if out, err := exec.Command("go", "env", "GOMODCACHE").Output(); err == nil {
+			goModCache = strings.TrimSpace(string(out))
+		}
+		if len(goModCache) == 0 {
+			goModCache = filepath.Join(gopath(), "pkg", "mod")
+		}
+	}
+	return goModCache
+}
+
+//escapeModulePath converts path to the form that is used
+//in the module cache: all upper case letters are replaced
+//by exclamation mark and lower case letter.
+func escapeModulePath(path string) string {
+	var result []rune
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			result = append(result, '!', unicode.ToLower(r))
+		} else {
+			result = append(result, r)
+		}
+	}
+	return string(result)
+}
+
+func unescapeModulePath(path string) string {
+	var result []rune
+	upper := false
+	for _, r := range path {
+		if r == '!' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		result = append(result, r)
+	}
+	return string(result)
+}
+
+func hasPathPrefix(path string, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+//gopath returns first entry of $GOPATH or default go path
+//if variable is not set.
+func gopath() string {
+	gopath := os.Getenv("GOPATH")
+	if len(gopath) == 0 {
+		gopath = build.Default.GOPATH
+	}
+	return filepath.SplitList(gopath)[0]
+}
+
+func goroot() string {
+	if goroot := os.Getenv("GOROOT"); len(goroot) > 0 {
+		return goroot
+	}
+	return build.Default.GOROOT
+}
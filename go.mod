@@ -0,0 +1,14 @@
+module github.com/dooman87/gounexport
+
+go 1.25.0
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	golang.org/x/tools v0.45.0
+	gopkg.in/yaml.v2 v2.4.0
+)
+
+require (
+	golang.org/x/mod v0.36.0 // indirect
+	golang.org/x/sync v0.20.0 // indirect
+)
@@ -16,14 +16,92 @@
 package gounexport
 
 import (
+	"fmt"
 	"go/ast"
 	"go/token"
 	"go/types"
 	"reflect"
+	"strings"
 )
 
+//Kind is a kind of the definition
+type Kind string
+
+//Kinds of definitions
+const (
+	KindType            Kind = "type"
+	KindInterface       Kind = "interface"
+	KindField           Kind = "field"
+	KindMethod          Kind = "method"
+	KindFunc            Kind = "func"
+	KindVar             Kind = "var"
+	KindConst           Kind = "const"
+	KindInterfaceMethod Kind = "interfacemethod"
+)
+
+//AllKinds contains all kinds of definitions
+var AllKinds = []Kind{KindType, KindInterface, KindField, KindMethod,
+	KindFunc, KindVar, KindConst, KindInterfaceMethod}
+
+//ParseKinds converts names to kinds. Returns error
+//if any of names is not a known kind.
+func ParseKinds(names []string) ([]Kind, error) {
+	var result []Kind
+	for _, name := range names {
+		kind := Kind(strings.TrimSpace(name))
+		if !kind.isValid() {
+			return nil, fmt.Errorf("unknown kind [%s], expected one of %v", name, AllKinds)
+		}
+		result = append(result, kind)
+	}
+	return result, nil
+}
+
+func (kind Kind) isValid() bool {
+	for _, k := range AllKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+//kindOf returns kind of the object or empty
+//string if object is not a definition (e.g. label)
+func kindOf(obj types.Object) Kind {
+	switch obj := obj.(type) {
+	case *types.TypeName:
+		if types.IsInterface(obj.Type()) {
+			return KindInterface
+		}
+		return KindType
+	case *types.Func:
+		sig, ok := obj.Type().(*types.Signature)
+		if !ok || sig.Recv() == nil {
+			return KindFunc
+		}
+		if types.IsInterface(sig.Recv().Type()) {
+			return KindInterfaceMethod
+		}
+		return KindMethod
+	case *types.Var:
+		if obj.IsField() {
+			return KindField
+		}
+		return KindVar
+	case *types.Const:
+		return KindConst
+	}
+	return ""
+}
+
 //Definition of symbol in package
 type Definition struct {
+	//ID is a canonical identifier of the definition that is used as a key
+	//of definitions map. It's built from the package path and names from
+	//the package scope (e.g. github.com/user/pkg#T.Method), so it's stable
+	//between runs and build contexts and unique even if full names are the same.
+	ID string
 	//Full file path for current defintion
 	File string
 	//Full name of the definition
@@ -37,6 +115,11 @@ type Definition struct {
 	Interfaces []*Definition
 	//type of definition
 	TypeOf reflect.Type
+	//Kind of definition: type, interface, field, method and so on
+	Kind Kind
+	//Reflection is a reason why definition is reachable through
+	//reflection, e.g. "json tag". Empty if it's not reachable.
+	Reflection string
 	//Number of line in the file where definition is declared
 	Line int
 	//Column in the file where definition is declared
@@ -49,18 +132,57 @@ type Definition struct {
 	Pkg *types.Package
 	//List of usages of the definition
 	Usages []*Usage
+
+	obj types.Object
+	//Offsets of the function or method in source file,
+	//they are used to find usages inside of it
+	bodyStart int
+	bodyEnd   int
 }
 
-func (def *Definition) addUsage(pos token.Position) {
+func (def *Definition) addUsage(pos token.Position, pkg string) *Usage {
 	u := new(Usage)
 	u.Pos = pos
+	u.Pkg = pkg
 	def.Usages = append(def.Usages, u)
+	return u
+}
+
+//addDynamicUsage adds usage with evidence if there
+//is no the same usage at the position.
+func (def *Definition) addDynamicUsage(pos token.Position, pkg string, evidence string) {
+	u := new(Usage)
+	u.Pos = pos
+	u.Pkg = pkg
+	u.Evidence = evidence
+	if !hasUsage(def, u) {
+		def.Usages = append(def.Usages, u)
+	}
 }
 
 //Usage is a struct that define one usage of a definition
 type Usage struct {
 	//Pos is a position of usage: file, line, col
 	Pos token.Position
+	//Pkg is a path of the package where usage is located.
+	//It ends with _test for external test packages.
+	Pkg string
+	//Evidence describes dynamic usage that is found by heuristics,
+	//e.g. reflect.Value.MethodByName("Foo") or {{.Foo}} in template.
+	//Pos is pointing to the call or the template string in this case
+	//and it's not renamed. Empty for usages from go/types.
+	Evidence string
+
+	//scope is the innermost scope of the usage and ident is a
+	//position of the identifier. They are used to check that the
+	//new name is not shadowed at the usage, see checkRename.
+	scope *types.Scope
+	ident token.Pos
+}
+
+//isTest returns true if usage is located in a test file
+func (u *Usage) isTest() bool {
+	return strings.HasSuffix(u.Pos.Filename, "_test.go")
 }
 
 type objectWithIdent struct {
@@ -82,18 +204,28 @@ type defWithInterface struct {
 }
 
 type context struct {
-	structs    map[string]string
-	vars       []*objectWithIdent
-	funcs      []*objectWithIdent
-	interfaces []*defWithInterface
-	fset       *token.FileSet
-	defs       map[string]*Definition
+	//memberPaths are paths of fields and interface methods
+	//of indexed packages, see memberPath
+	memberPaths map[types.Object]string
+	indexedPkgs map[*types.Package]bool
+	structs     map[string]string
+	filePkgs    map[string]string
+	files       map[string]*types.Package
+	vars        []*objectWithIdent
+	funcs       []*objectWithIdent
+	interfaces  []*defWithInterface
+	fset        *token.FileSet
+	defs        map[string]*Definition
 }
 
 func newContext(fset *token.FileSet) *context {
 	ctx := new(context)
 	ctx.fset = fset
 	ctx.structs = make(map[string]string, 0)
+	ctx.filePkgs = make(map[string]string, 0)
+	ctx.files = make(map[string]*types.Package, 0)
+	ctx.memberPaths = make(map[types.Object]string)
+	ctx.indexedPkgs = make(map[*types.Package]bool)
 	ctx.interfaces = make([]*defWithInterface, 0)
 	ctx.vars = make([]*objectWithIdent, 0)
 	ctx.funcs = make([]*objectWithIdent, 0)
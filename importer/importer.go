@@ -20,10 +20,12 @@ package importer
 
 import (
 	"go/ast"
+	"go/build"
 	"go/importer"
 	"go/parser"
 	"go/token"
 	"go/types"
+	"path/filepath"
 	"strings"
 
 	"github.com/dooman87/gounexport/fs"
@@ -37,14 +39,27 @@ type CollectInfoImporter struct {
 	//Info struct that will be filled by Collect() method
 	Info *types.Info
 	//Package that should be a start point to collect info
-	Pkg      string
+	Pkg string
+	//Pkgs are packages that are analyzed together with Pkg. If they
+	//are imported by Pkg, then their info and tests are collected as
+	//well, so Collect returns the same package for them later.
+	Pkgs []string
+	//Context is used to match files by build constraints,
+	//GOOS and GOARCH. build.Default is used if it's not set.
+	Context *build.Context
+	//Errors contains all type errors that were found while
+	//checking packages from sources. Collect doesn't fail on
+	//type errors, so caller should check them.
+	Errors   []types.Error
 	fset     *token.FileSet
-	astFiles []*ast.File
 	packages map[string]*types.Package
 }
 
-func (*CollectInfoImporter) errorHandler(err error) {
+func (_importer *CollectInfoImporter) errorHandler(err error) {
 	util.Warn("error while checking source: %v", err)
+	if typeErr, ok := err.(types.Error); ok {
+		_importer.Errors = append(_importer.Errors, typeErr)
+	}
 }
 
 var (
@@ -54,34 +69,24 @@ var (
 //Collect going through package and collect info
 //using conf.Check method. It's using this implementation
 //of importer for check all inner packages and go/types/importer.Default()
-//to check all built in packages (without sources)
+//to check all built in packages (without sources).
+//External test package (package with _test suffix) is checked
+//as a separate package after the main one. If the package was
+//already imported from sources, then it's returned as is.
 func (_importer *CollectInfoImporter) Collect() (*types.Package, *token.FileSet, error) {
-	var conf types.Config
-	conf.Importer = _importer
-	conf.Error = _importer.errorHandler
-
 	if _importer.packages == nil {
 		_importer.packages = make(map[string]*types.Package)
 	}
-
-	var pkg *types.Package
-	var err error
-	var files []string
-
-	if files, err = fs.SourceFiles(_importer.Pkg, false); err != nil {
-		return nil, nil, err
+	if pkg := _importer.packages[_importer.Pkg]; pkg != nil && _importer.isAnalyzed(_importer.Pkg) {
+		util.Debug("package [%s] has been already imported", pkg.Name())
+		return pkg, _importer.fset, nil
 	}
-	if _importer.fset, _importer.astFiles, err = doParseFiles(files, _importer.fset); err != nil {
+
+	pkg, err := _importer.doImport(_importer.Pkg, true)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	//XXX: return positive result if check() returns error.
-	pkg, _ = conf.Check(_importer.Pkg, _importer.fset, _importer.astFiles, _importer.Info)
-	// if pkg, err = conf.Check(_importer.Pkg, _importer.fset, _importer.astFiles, _importer.Info); err != nil {
-	// 	return pkg, _importer.fset, err
-	// }
-
-	_importer.packages[_importer.Pkg] = pkg
 	util.Debug("package [%s] successfully parsed\n", pkg.Name())
 
 	return pkg, _importer.fset, nil
@@ -100,15 +105,15 @@ func (_importer *CollectInfoImporter) Import(path string) (*types.Package, error
 	var pkg *types.Package
 	var err error
 
-	if strings.Contains(path, _importer.Pkg) {
+	if _importer.isAnalyzed(path) {
 		if pkg, err = _importer.doImport(path, true); err != nil {
 			return pkg, err
 		}
-	}
-
-	pkg, err = defaultImporter.Import(path)
-	if err != nil {
-		pkg, err = _importer.doImport(path, true)
+	} else {
+		pkg, err = defaultImporter.Import(path)
+		if err != nil {
+			pkg, err = _importer.doImport(path, true)
+		}
 	}
 
 	if pkg != nil {
@@ -125,43 +130,104 @@ func (_importer *CollectInfoImporter) doImport(path string, collectInfo bool) (*
 	conf.Importer = _importer
 	conf.Error = _importer.errorHandler
 
+	info := _importer.Info
+	if !collectInfo {
+		info = nil
+	}
+
 	files, err := fs.SourceFiles(path, false)
 	if err != nil {
 		return nil, err
 	}
 
-	fset, astFiles, err := doParseFiles(files, _importer.fset)
+	var astFiles, testAstFiles []*ast.File
+	files = MatchFiles(_importer.Context, files)
+	//Tests of dependencies can't use the package, but they
+	//could import packages that are not available.
+	if !_importer.isAnalyzed(path) {
+		files = withoutTests(files)
+	}
+	_importer.fset, astFiles, testAstFiles, err = doParseFiles(files, _importer.fset)
 	if err != nil {
 		return nil, err
 	}
 
-	if collectInfo {
-		pkg, err = conf.Check(path, fset, astFiles, _importer.Info)
-	} else {
-		pkg, err = conf.Check(path, fset, astFiles, nil)
+	//Type errors are collected by errorHandler, so partial result
+	//is returned and analysis is continued.
+	pkg, _ = conf.Check(path, _importer.fset, astFiles, info)
+	_importer.packages[path] = pkg
+
+	//External test package is importing the package
+	//that we just checked, so it should be cached before.
+	if len(testAstFiles) > 0 {
+		testPath := path + "_test"
+		util.Info("checking external test package [%s]", testPath)
+		testPkg, _ := conf.Check(testPath, _importer.fset, testAstFiles, info)
+		_importer.packages[testPath] = testPkg
 	}
 	return pkg, err
 }
 
-func doParseFiles(filePathes []string, fset *token.FileSet) (*token.FileSet, []*ast.File, error) {
+//isAnalyzed returns true if path is Pkg, one of Pkgs
+//or a package inside of them
+func (_importer *CollectInfoImporter) isAnalyzed(path string) bool {
+	for _, pkg := range append([]string{_importer.Pkg}, _importer.Pkgs...) {
+		if path == pkg || strings.HasPrefix(path, pkg+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+//MatchFiles returns files that should be included to the
+//build using context. If ctxt is nil then build.Default is used.
+func MatchFiles(ctxt *build.Context, files []string) []string {
+	if ctxt == nil {
+		ctxt = &build.Default
+	}
+	var result []string
+	for _, f := range files {
+		match, err := ctxt.MatchFile(filepath.Dir(f), filepath.Base(f))
+		if err != nil {
+			util.Warn("error while matching file [%s]: %v", f, err)
+		}
+		if match {
+			result = append(result, f)
+		} else {
+			util.Debug("file [%s] is excluded by build context", f)
+		}
+	}
+	return result
+}
+
+func withoutTests(files []string) []string {
+	var result []string
+	for _, f := range files {
+		if !strings.HasSuffix(f, "_test.go") {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+//doParseFiles parses all files and splits result to files
+//of the package and files of the external test package
+//(package name ends with _test).
+func doParseFiles(filePathes []string, fset *token.FileSet) (*token.FileSet, []*ast.File, []*ast.File, error) {
 	if fset == nil {
 		fset = token.NewFileSet()
 	}
 	util.Info("parsing files %v", filePathes)
 	astFiles := make([]*ast.File, 0, len(filePathes))
+	var testAstFiles []*ast.File
 	for _, f := range filePathes {
-		//XXX: Ignoring files with packages ends with _test.
-		//XXX: Doing that because getting error in check()
-		//XXX: cause source file is still going to current
-		//XXX: packages. Need to analyze package before
-		//XXX: and check both packages separately.
-		tempFset := token.NewFileSet()
-		astFile, err := parser.ParseFile(tempFset, f, nil, 0)
-		if !strings.HasSuffix(astFile.Name.Name, "_test") {
-			if err != nil {
-				return nil, nil, err
-			}
-			astFile, _ := parser.ParseFile(fset, f, nil, 0)
+		astFile, err := parser.ParseFile(fset, f, nil, 0)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if strings.HasSuffix(f, "_test.go") && strings.HasSuffix(astFile.Name.Name, "_test") {
+			testAstFiles = append(testAstFiles, astFile)
+		} else {
 			astFiles = append(astFiles, astFile)
 		}
 	}
@@ -171,5 +237,5 @@ func doParseFiles(filePathes []string, fset *token.FileSet) (*token.FileSet, []*
 		return true
 	}
 	fset.Iterate(iterateFunc)
-	return fset, astFiles, nil
+	return fset, astFiles, testAstFiles, nil
 }
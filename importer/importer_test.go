@@ -41,3 +41,26 @@ func TestCollect(t *testing.T) {
 		t.Fatal("package should not be nil")
 	}
 }
+
+func TestCollectExternalTest(t *testing.T) {
+	info := types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+
+	importer := new(CollectInfoImporter)
+	importer.Pkg = pkg + "/testexternal"
+	importer.Info = &info
+	if _, _, err := importer.Collect(); err != nil {
+		t.Fatalf("error while collect info from %s, %v", importer.Pkg, err)
+	}
+
+	testPkg := importer.packages[importer.Pkg+"_test"]
+	if testPkg == nil {
+		t.Fatalf("external test package should be checked")
+	}
+	if testPkg.Scope().Lookup("TestUsedInTest") == nil {
+		t.Errorf("expected TestUsedInTest in external test package")
+	}
+}
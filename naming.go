@@ -0,0 +1,233 @@
+package gounexport
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dooman87/gounexport/util"
+)
+
+//NamingStrategy is a way to convert exported name to unexported one
+type NamingStrategy string
+
+const (
+	//NamingFirstLetter changes only the first letter: HTTPClient -> hTTPClient
+	NamingFirstLetter NamingStrategy = "first"
+	//NamingInitialisms changes the leading initialism: HTTPClient -> httpClient, ID -> id
+	NamingInitialisms NamingStrategy = "initialisms"
+)
+
+//AllNamingStrategies are all supported naming strategies
+var AllNamingStrategies = []NamingStrategy{NamingFirstLetter, NamingInitialisms}
+
+//Naming chooses new names of definitions for UnexportWithNaming.
+//Names that were chosen are remembered, so the same Naming
+//should be used for all definitions that are renamed together.
+type Naming struct {
+	//Strategy is used for definitions that are not in Mapping
+	Strategy NamingStrategy
+	//Mapping contains new names by full names (pkg.Type.Field)
+	//or simple names of definitions. Full names have priority.
+	Mapping map[string]string
+	//FallbackSuffix is added to the new name if it conflicts
+	//with other identifier: type -> type_
+	FallbackSuffix string
+	//FallbackPrefix is added to the original name if the new
+	//name conflicts with other identifier: Type -> xType
+	FallbackPrefix string
+
+	//assigned are definitions by full names that were renamed
+	assigned map[string]*Definition
+	//byName are definitions from indexedDefs by full names
+	byName      map[string]*Definition
+	indexedDefs map[string]*Definition
+	//pkgTypes are types of packages, see packageTypes
+	pkgTypes map[*types.Package][]types.Type
+}
+
+//NewNaming creates naming with NamingInitialisms strategy
+func NewNaming() *Naming {
+	naming := new(Naming)
+	naming.Strategy = NamingInitialisms
+	naming.Mapping = make(map[string]string)
+	naming.assigned = make(map[string]*Definition)
+	return naming
+}
+
+//ParseNamingStrategy returns naming strategy by its name
+func ParseNamingStrategy(name string) (NamingStrategy, error) {
+	for _, strategy := range AllNamingStrategies {
+		if string(strategy) == strings.TrimSpace(name) {
+			return strategy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown naming strategy [%s]", name)
+}
+
+//ReadNamingMapping reads mapping of names from the file. Each line
+//contains original name (full or simple) and the new name separated
+//by spaces, e.g.
+//  github.com/user/pkg.HTTPClient httpc
+//  ID ident
+//Empty lines and lines started with # are skipped.
+func ReadNamingMapping(file string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	mapping := make(map[string]string)
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected original and new names, but found [%s]", file, i+1, line)
+		}
+		if !isUnexportedIdentifier(fields[1]) {
+			return nil, fmt.Errorf("%s:%d: [%s] is not an unexported identifier", file, i+1, fields[1])
+		}
+		mapping[fields[0]] = fields[1]
+	}
+	return mapping, nil
+}
+
+//names returns new names of definition in order of preference
+func (naming *Naming) names(def *Definition) []string {
+	preferred, ok := naming.Mapping[def.Name]
+	if !ok {
+		preferred, ok = naming.Mapping[def.SimpleName]
+	}
+	if !ok {
+		switch naming.Strategy {
+		case NamingInitialisms:
+			preferred = lowerInitialism(def.SimpleName)
+		default:
+			preferred = strings.ToLower(def.SimpleName[0:1]) + def.SimpleName[1:]
+		}
+	}
+
+	names := []string{preferred}
+	if len(naming.FallbackSuffix) > 0 {
+		names = append(names, preferred+naming.FallbackSuffix)
+	}
+	if len(naming.FallbackPrefix) > 0 {
+		names = append(names, naming.FallbackPrefix+def.SimpleName)
+	}
+	return names
+}
+
+//newName returns the first name of definition that doesn't conflict
+//with other identifiers. If all names are conflicting, then the error
+//of preferred name is returned.
+func (naming *Naming) newName(def *Definition, allDefs map[string]*Definition) (string, error) {
+	var firstErr error
+	for _, name := range naming.names(def) {
+		err := naming.check(def, name, allDefs)
+		if err == nil {
+			return name, nil
+		}
+		util.Debug("name [%s] can't be used: %v", name, err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return "", firstErr
+}
+
+//check returns error if definition can't be renamed to newName
+func (naming *Naming) check(def *Definition, newName string, allDefs map[string]*Definition) error {
+	if err := checkRename(def, newName, naming.packageTypes); err != nil {
+		return err
+	}
+	if !isUnexportedIdentifier(newName) {
+		return fmt.Errorf("can't rename %s to %s, because it's not an unexported identifier", def.Name, newName)
+	}
+	lastIdx := strings.LastIndex(def.Name, def.SimpleName)
+	newFullName := def.Name[0:lastIdx] + newName + def.Name[lastIdx+len(def.SimpleName):]
+	if naming.definitionByName(allDefs, newFullName) != nil {
+		return fmt.Errorf("can't unexport %s because it conflicts with existing member", def.Name)
+	}
+	if other := naming.assigned[newFullName]; other != nil && other != def {
+		return fmt.Errorf("can't rename %s to %s, because %s is renamed to it", def.Name, newName, other.Name)
+	}
+	if naming.assigned != nil {
+		naming.assigned[newFullName] = def
+	}
+	return nil
+}
+
+//commonInitialisms are initialisms that are lowercased as one word,
+//the same list is used by golint
+var commonInitialisms = []string{"ACL", "API", "ASCII", "CPU", "CSS", "DNS",
+	"EOF", "GUID", "HTML", "HTTP", "HTTPS", "ID", "IP", "JSON", "LHS", "QPS",
+	"RAM", "RHS", "RPC", "SLA", "SMTP", "SQL", "SSH", "TCP", "TLS", "TTL",
+	"UDP", "UI", "UID", "UUID", "URI", "URL", "UTF8", "VM", "XML", "XMPP",
+	"XSRF", "XSS"}
+
+//definitionByName returns definition by full name. Definitions are
+//indexed once, so renaming of all definitions doesn't scan allDefs
+//for each of them.
+func (naming *Naming) definitionByName(allDefs map[string]*Definition, name string) *Definition {
+	if naming.byName == nil || reflect.ValueOf(naming.indexedDefs).Pointer() != reflect.ValueOf(allDefs).Pointer() {
+		naming.byName = make(map[string]*Definition, len(allDefs))
+		naming.indexedDefs = allDefs
+		for _, def := range allDefs {
+			naming.byName[def.Name] = def
+		}
+	}
+	return naming.byName[name]
+}
+
+//packageTypes returns types of the package, they are
+//collected once for all definitions of the package
+func (naming *Naming) packageTypes(pkg *types.Package) []types.Type {
+	if naming.pkgTypes == nil {
+		naming.pkgTypes = make(map[*types.Package][]types.Type)
+	}
+	if _, ok := naming.pkgTypes[pkg]; !ok {
+		naming.pkgTypes[pkg] = packageTypes(pkg)
+	}
+	return naming.pkgTypes[pkg]
+}
+
+//lowerInitialism lowercases the leading initialism from commonInitialisms,
+//other names are lowercased by the first letter: HTTPClient -> httpClient,
+//ID -> id, HTTP2Server -> http2Server, XMLHTTPRequest -> xmlHTTPRequest.
+//Plural initialisms are ending with lower case s: URLs -> urls.
+func lowerInitialism(name string) string {
+	_, lead := utf8.DecodeRuneInString(name)
+	for _, initialism := range commonInitialisms {
+		if len(initialism) > lead && isLeadingInitialism(name, initialism) {
+			lead = len(initialism)
+		}
+	}
+	return strings.ToLower(name[0:lead]) + name[lead:]
+}
+
+//isLeadingInitialism returns true if name starts with initialism and
+//it's followed by the next word, digit, plural s or the end of name
+func isLeadingInitialism(name string, initialism string) bool {
+	if !strings.HasPrefix(name, initialism) {
+		return false
+	}
+	rest := name[len(initialism):]
+	if strings.HasPrefix(rest, "s") {
+		rest = rest[1:]
+		if len(rest) > 0 && !unicode.IsUpper(rune(rest[0])) {
+			return false
+		}
+	}
+	return len(rest) == 0 || unicode.IsUpper(rune(rest[0])) || unicode.IsDigit(rune(rest[0]))
+}
+
+func isUnexportedIdentifier(name string) bool {
+	return token.IsIdentifier(name) && !token.IsExported(name)
+}
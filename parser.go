@@ -4,6 +4,8 @@ import (
 	"github.com/dooman87/gounexport/fs"
 	"github.com/dooman87/gounexport/importer"
 	"github.com/dooman87/gounexport/util"
+	"go/ast"
+	"go/build"
 	"go/token"
 	"go/types"
 )
@@ -11,46 +13,138 @@ import (
 //ParsePackage parses package and filling info structure.
 //It's filling info about all internal packages even if they
 //are not imported in the root package.
+//Files are matched by build.Default context.
+//If there are type errors, then partial results are returned
+//together with TypeErrors.
 func ParsePackage(pkgName string, info *types.Info) (*types.Package, *token.FileSet, error) {
+	return ParsePackageWithContext(pkgName, info, nil)
+}
+
+//ParsePackageWithContext is the same as ParsePackage, but only
+//files that are matched by build context ctxt are parsed. If ctxt is nil,
+//then build.Default is used.
+func ParsePackageWithContext(pkgName string, info *types.Info, ctxt *build.Context) (*types.Package, *token.FileSet, error) {
+	pkgs, fset, err := ParsePackagesWithContext([]string{pkgName}, info, ctxt)
+	if len(pkgs) == 0 {
+		return nil, fset, err
+	}
+	return pkgs[0], fset, err
+}
+
+//ParsePackagesWithContext parses several packages with all internal
+//packages to the same info structure and file set. Packages are
+//sharing imported packages, so usages of definitions from one
+//package in another are recognized. Returns parsed packages in
+//the same order as pkgNames.
+func ParsePackagesWithContext(pkgNames []string, info *types.Info, ctxt *build.Context) ([]*types.Package, *token.FileSet, error) {
 	collectImporter := new(importer.CollectInfoImporter)
 	collectImporter.Info = info
+	collectImporter.Context = ctxt
+	collectImporter.Pkgs = pkgNames
 
-	var resultPkg *types.Package
+	var resultPkgs []*types.Package
 	var resultFset *token.FileSet
 	parsedPackages := make(map[string]bool)
 
-	notParsedPackage := pkgName
-	for len(notParsedPackage) > 0 {
-		collectImporter.Pkg = notParsedPackage
-		pkg, fset, err := collectImporter.Collect()
-		if err != nil {
-			return nil, nil, err
-		}
+	for _, pkgName := range pkgNames {
+		var resultPkg *types.Package
+		notParsedPackage := pkgName
+		for len(notParsedPackage) > 0 {
+			collectImporter.Pkg = notParsedPackage
+			pkg, fset, err := collectImporter.Collect()
+			if err != nil {
+				return nil, nil, err
+			}
 
-		//Filling results only from first package
-		//that was passed as argument to function
-		if resultPkg == nil {
-			resultPkg = pkg
+			//Filling results only from first package
+			//that was passed as argument to function
+			if resultPkg == nil {
+				resultPkg = pkg
+			}
 			resultFset = fset
+			parsedPackages[notParsedPackage] = true
+
+			//Searching for a new package that was not parsed before
+			notParsedPackage = ""
+			files, err := fs.GetUnusedSources(pkgName, fset)
+			if err != nil {
+				return nil, nil, err
+			}
+			for _, f := range importer.MatchFiles(ctxt, files) {
+				newNotParsedPackage := fs.GetPackagePath(f)
+				if !parsedPackages[newNotParsedPackage] {
+					notParsedPackage = newNotParsedPackage
+					break
+				} else {
+					util.Info("package %s has been already parsed, however %s file is still unused", newNotParsedPackage, f)
+				}
+			}
 		}
-		parsedPackages[notParsedPackage] = true
+		resultPkgs = append(resultPkgs, resultPkg)
+	}
 
-		//Searching for a new package that was not parsed before
-		notParsedPackage = ""
-		files, err := fs.GetUnusedSources(pkgName, fset)
-		if err != nil {
-			return nil, nil, err
+	return resultPkgs, resultFset, TypeErrors(collectImporter.Errors).Err()
+}
+
+//TypeCheck parses packages and all inner packages for each of build
+//contexts and returns all type errors. If contexts are empty then
+//build.Default is used. Error is returned only if packages can't
+//be parsed.
+func TypeCheck(pkgNames []string, contexts []*build.Context) (TypeErrors, error) {
+	if len(contexts) == 0 {
+		contexts = []*build.Context{&build.Default}
+	}
+
+	var result TypeErrors
+	for _, ctxt := range contexts {
+		info := &types.Info{
+			Types: make(map[ast.Expr]types.TypeAndValue),
+			Defs:  make(map[*ast.Ident]types.Object),
+			Uses:  make(map[*ast.Ident]types.Object),
 		}
-		for _, f := range files {
-			newNotParsedPackage := fs.GetPackagePath(f)
-			if !parsedPackages[newNotParsedPackage] {
-				notParsedPackage = newNotParsedPackage
-				break
-			} else {
-				util.Info("package %s has been already parsed, however %s file is still unused", newNotParsedPackage, f)
-			}
+		_, _, err := ParsePackagesWithContext(pkgNames, info, ctxt)
+		if typeErrors, ok := err.(TypeErrors); ok {
+			result = appendTypeErrors(result, typeErrors)
+		} else if err != nil {
+			return nil, err
 		}
 	}
+	return result, nil
+}
+
+//GetDefinitionsWithContexts parses package and collects definitions
+//for each build context separately. Result is merged, so definition
+//is unused only if it is unused in all contexts.
+//If contexts are empty then build.Default is used.
+//If there are type errors, then definitions are returned
+//together with TypeErrors of all contexts.
+func GetDefinitionsWithContexts(pkgName string, contexts []*build.Context) (map[string]*Definition, error) {
+	return GetPackagesDefinitions([]string{pkgName}, contexts)
+}
+
+//GetPackagesDefinitions is the same as GetDefinitionsWithContexts,
+//but several packages are parsed together, see ParsePackagesWithContext.
+func GetPackagesDefinitions(pkgNames []string, contexts []*build.Context) (map[string]*Definition, error) {
+	if len(contexts) == 0 {
+		contexts = []*build.Context{&build.Default}
+	}
 
-	return resultPkg, resultFset, nil
+	result := make(map[string]*Definition)
+	var typeErrors TypeErrors
+	for _, ctxt := range contexts {
+		util.Info("parsing packages %v for [%s/%s] %v", pkgNames, ctxt.GOOS, ctxt.GOARCH, ctxt.BuildTags)
+		info := &types.Info{
+			Types: make(map[ast.Expr]types.TypeAndValue),
+			Defs:  make(map[*ast.Ident]types.Object),
+			Uses:  make(map[*ast.Ident]types.Object),
+		}
+		_, fset, err := ParsePackagesWithContext(pkgNames, info, ctxt)
+		if errs, ok := err.(TypeErrors); ok {
+			typeErrors = appendTypeErrors(typeErrors, errs)
+		} else if err != nil {
+			return nil, err
+		}
+		MergeDefinitions(result, GetDefinitions(info, fset))
+	}
+	return result, typeErrors.Err()
 }
@@ -1,8 +1,12 @@
 package gounexport_test
 
 import (
+	"go/ast"
 	"go/token"
+	"go/types"
 	"testing"
+
+	"github.com/dooman87/gounexport"
 )
 
 func TestParsePackageFunc(t *testing.T) {
@@ -18,3 +22,22 @@ func TestParsePackageFunc(t *testing.T) {
 		t.Errorf("expected 2 files in result file set but found %d", fileCounter)
 	}
 }
+
+func TestParsePackagesImported(t *testing.T) {
+	lib := pkg + "/testmulti/lib"
+	app := pkg + "/testmulti/app"
+	info := &types.Info{
+		Defs: make(map[*ast.Ident]types.Object),
+		Uses: make(map[*ast.Ident]types.Object),
+	}
+
+	//lib is imported by app, so it's not parsed again
+	pkgs, _, err := gounexport.ParsePackagesWithContext([]string{app, lib}, info, nil)
+	if err != nil {
+		t.Fatalf("error while parsing packages %v", err)
+	}
+	imports := pkgs[0].Imports()
+	if len(imports) != 1 || imports[0] != pkgs[1] {
+		t.Errorf("expected %v to be imported by %v, but found %v", pkgs[1], pkgs[0], imports)
+	}
+}
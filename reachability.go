@@ -0,0 +1,277 @@
+package gounexport
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+
+	"github.com/dooman87/gounexport/util"
+)
+
+//FindUnreachableDefinitions returns definitions of packages that are not
+//reachable from roots. Reference graph is built from usages: definition
+//references everything that is used inside of its declaration (function
+//body, initializer of variable, fields of struct and so on). Roots are:
+// - main and init functions
+// - test, benchmark, example and fuzz functions, unless opts.IgnoreTestUsages is set
+// - definitions with full names that are matched by opts.Roots
+// - exported definitions of packages that are not main, if opts.APIRoots is set
+// - definitions that are used outside of packages, e.g. by consumers
+// - definitions that are reachable through reflection, unless opts.IncludeReflected is set
+// - definitions that are marked by KeepDirective
+//Methods that implement interfaces are reachable if their receiver
+//type is reachable. Unlike FindDeadDefinitions, definitions that are
+//referencing each other, but not reachable from roots, are returned.
+//Excludes and Kinds options are applied to the result.
+func FindUnreachableDefinitions(pkgs []string, defs map[string]*Definition, opts *Options) []*Definition {
+	directives := opts.Directives
+	if directives == nil {
+		directives = NewDirectives()
+	}
+
+	graph := newReferenceGraph(pkgs, defs, opts)
+	reachable := make(map[*Definition]bool)
+	var roots []*Definition
+	for _, def := range defs {
+		if graph.isRoot(def, pkgs, opts) {
+			roots = append(roots, def)
+		}
+	}
+
+	var candidates []*Definition
+	for _, def := range defs {
+		//Methods that implement interfaces are referenced by their
+		//receiver types, so they are unreachable together with types
+		isMethod := def.Kind == KindMethod && len(def.Interfaces) > 0
+		if isTestPackage(def) || !isInPackages(def, pkgs) || !(isDeadCandidate(def) || isMethod) {
+			continue
+		}
+		directives.load(def.File)
+		candidates = append(candidates, def)
+	}
+
+	//Definitions that are kept by directives are roots as well, but
+	//they are added only if they are not reachable from other roots
+	//to find stale directives.
+	for len(roots) > 0 {
+		graph.walk(roots, reachable)
+		roots = nil
+		for _, def := range candidates {
+			if !reachable[def] && directives.keeps(def) {
+				roots = append(roots, def)
+			}
+		}
+	}
+
+	var result []*Definition
+	for _, def := range candidates {
+		if !reachable[def] && !isExcluded(def, opts.Excludes) && hasKind(def, opts.Kinds) {
+			util.Info("adding [%s] to unreachable list", def.Name)
+			result = append(result, def)
+		}
+	}
+	return result
+}
+
+//referenceGraph contains definitions that are
+//referenced by each definition
+type referenceGraph struct {
+	refs map[*Definition][]*Definition
+	//external are definitions that are used outside
+	//of declarations of analyzed packages
+	external map[*Definition]bool
+}
+
+//newReferenceGraph creates graph from usages of definitions. Each usage
+//is mapped to top level declaration where it's located. Usages outside of
+//packages or declarations are external.
+func newReferenceGraph(pkgs []string, defs map[string]*Definition, opts *Options) *referenceGraph {
+	graph := new(referenceGraph)
+	graph.refs = make(map[*Definition][]*Definition)
+	graph.external = make(map[*Definition]bool)
+
+	positions := make(map[string]map[int]*Definition)
+	names := make(map[string]*Definition)
+	for _, def := range defs {
+		names[def.Name] = def
+		if len(def.File) == 0 {
+			continue
+		}
+		file := filepath.Clean(def.File)
+		if positions[file] == nil {
+			positions[file] = make(map[int]*Definition)
+		}
+		positions[file][def.Offset] = def
+	}
+
+	decls := newDeclIndex()
+	for _, def := range defs {
+		for _, u := range def.Usages {
+			if !u.Pos.IsValid() || (opts.IgnoreTestUsages && u.isTest()) {
+				continue
+			}
+			if len(u.Evidence) > 0 {
+				if !opts.IncludeReflected {
+					graph.external[def] = true
+				}
+				continue
+			}
+			if !isPackageIn(u.Pkg, pkgs) {
+				graph.external[def] = true
+				continue
+			}
+			offsets := decls.enclosing(u.Pos)
+			if len(offsets) == 0 {
+				graph.external[def] = true
+				continue
+			}
+			for _, offset := range offsets {
+				if from := positions[filepath.Clean(u.Pos.Filename)][offset]; from != nil && from != def {
+					graph.refs[from] = append(graph.refs[from], def)
+				}
+			}
+		}
+
+		//Method could be called through interface,
+		//so it's referenced by its receiver type
+		if def.Kind == KindMethod && len(def.Interfaces) > 0 {
+			if recv := names[def.Name[:strings.LastIndex(def.Name, ".")]]; recv != nil {
+				graph.refs[recv] = append(graph.refs[recv], def)
+			}
+		}
+	}
+	return graph
+}
+
+//isRoot returns true if definition is reachable
+//regardless of usages inside of packages
+func (graph *referenceGraph) isRoot(def *Definition, pkgs []string, opts *Options) bool {
+	if graph.external[def] || isReflected(def, opts) {
+		return true
+	}
+	if !isInPackages(def, pkgs) {
+		return false
+	}
+	if def.Kind == KindFunc {
+		if def.SimpleName == "main" || def.SimpleName == "init" {
+			return true
+		}
+		if !opts.IgnoreTestUsages && strings.HasSuffix(def.File, "_test.go") && isTestFunc(def.SimpleName) {
+			return true
+		}
+	}
+	if opts.APIRoots && def.Exported && def.Pkg != nil && def.Pkg.Name() != "main" && !isTestPackage(def) {
+		return true
+	}
+	for _, root := range opts.Roots {
+		if root.MatchString(def.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+//walk marks all definitions that are reachable from roots
+func (graph *referenceGraph) walk(roots []*Definition, reachable map[*Definition]bool) {
+	queue := roots
+	for len(queue) > 0 {
+		def := queue[0]
+		queue = queue[1:]
+		if reachable[def] {
+			continue
+		}
+		reachable[def] = true
+		queue = append(queue, graph.refs[def]...)
+	}
+}
+
+//isPackageIn returns true if pkgPath is one of pkgs
+//or a package inside of them
+func isPackageIn(pkgPath string, pkgs []string) bool {
+	for _, pkg := range pkgs {
+		if pkgPath == pkg || strings.HasPrefix(pkgPath, pkg+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+//declIndex maps positions to top level declarations of files.
+//Files are parsed on demand.
+type declIndex struct {
+	files map[string][]*declRange
+}
+
+//declRange is a range of top level declaration and offsets
+//of identifiers that are declared by it
+type declRange struct {
+	start   int
+	end     int
+	offsets []int
+}
+
+func newDeclIndex() *declIndex {
+	index := new(declIndex)
+	index.files = make(map[string][]*declRange)
+	return index
+}
+
+//enclosing returns offsets of identifiers that are declared by
+//top level declaration where the position is located
+func (index *declIndex) enclosing(pos token.Position) []int {
+	file := filepath.Clean(pos.Filename)
+	ranges, ok := index.files[file]
+	if !ok {
+		var err error
+		ranges, err = parseDecls(file)
+		if err != nil {
+			util.Warn("can't read declarations from [%s]: %v", file, err)
+		}
+		index.files[file] = ranges
+	}
+	for _, r := range ranges {
+		if r.start <= pos.Offset && pos.Offset < r.end {
+			return r.offsets
+		}
+	}
+	return nil
+}
+
+//parseDecls parses file and returns ranges of top level declarations.
+//Each spec of grouped declaration has its own range.
+func parseDecls(file string) ([]*declRange, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*declRange
+	add := func(node ast.Node, idents ...*ast.Ident) {
+		r := new(declRange)
+		r.start = fset.Position(node.Pos()).Offset
+		r.end = fset.Position(node.End()).Offset
+		for _, ident := range idents {
+			r.offsets = append(r.offsets, fset.Position(ident.Pos()).Offset)
+		}
+		result = append(result, r)
+	}
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			add(decl, decl.Name)
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					add(spec, spec.Name)
+				case *ast.ValueSpec:
+					add(spec, spec.Names...)
+				}
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,167 @@
+package gounexport
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+
+	"github.com/dooman87/gounexport/util"
+)
+
+//reflectionTags are keys of struct tags that are used by encoders.
+//Encoders are accessing tagged fields by reflection, so they
+//can't be unexported.
+var reflectionTags = []string{"json", "xml", "yaml", "toml", "db", "bson", "mapstructure", "msgpack"}
+
+//reflectionPackages are packages that are accessing exported fields
+//of values by reflection. If value is passed to function of the package
+//as interface{}, then all exported fields of its type are used. Templates
+//are also calling exported methods.
+var reflectionPackages = map[string]bool{
+	"encoding/json":                    true,
+	"encoding/xml":                     true,
+	"encoding/gob":                     true,
+	"text/template":                    true,
+	"html/template":                    true,
+	"gopkg.in/yaml.v2":                 true,
+	"gopkg.in/yaml.v3":                 true,
+	"github.com/BurntSushi/toml":       true,
+	"go.mongodb.org/mongo-driver/bson": true,
+}
+
+var templatePackages = map[string]bool{
+	"text/template": true,
+	"html/template": true,
+}
+
+//processReflection marks definitions that are reachable
+//through reflection: struct fields with tags of encoders and
+//types of values that are passed to encoders and templates.
+func processReflection(info *types.Info, ctx *context) {
+	for _, obj := range info.Defs {
+		if obj == nil {
+			continue
+		}
+		if typeName, ok := obj.(*types.TypeName); ok {
+			if s, ok := typeName.Type().Underlying().(*types.Struct); ok {
+				markTaggedFields(s, ctx)
+			}
+		}
+	}
+
+	for expr := range info.Types {
+		if call, ok := expr.(*ast.CallExpr); ok {
+			markCallArguments(call, info, ctx)
+		}
+	}
+}
+
+func markTaggedFields(s *types.Struct, ctx *context) {
+	for i := 0; i < s.NumFields(); i++ {
+		tag := reflect.StructTag(s.Tag(i))
+		for _, key := range reflectionTags {
+			if value, ok := tag.Lookup(key); ok && value != "-" {
+				markReflection(ctx.defs[objectID(s.Field(i), ctx, false)], key+" tag")
+				break
+			}
+		}
+	}
+}
+
+//markCallArguments marks types of arguments that are passed
+//as interface{} to functions from reflectionPackages.
+func markCallArguments(call *ast.CallExpr, info *types.Info, ctx *context) {
+	var ident *ast.Ident
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return
+	}
+	f, ok := info.Uses[ident].(*types.Func)
+	if !ok || f.Pkg() == nil || !reflectionPackages[f.Pkg().Path()] {
+		return
+	}
+	sig := f.Type().(*types.Signature)
+	reason := "passed to " + f.FullName()
+	methods := templatePackages[f.Pkg().Path()]
+
+	params := sig.Params()
+	for i, arg := range call.Args {
+		var param types.Type
+		switch {
+		case sig.Variadic() && i >= params.Len()-1:
+			param = params.At(params.Len() - 1).Type().(*types.Slice).Elem()
+		case i < params.Len():
+			param = params.At(i).Type()
+		default:
+			continue
+		}
+		if !isEmptyInterface(param) {
+			continue
+		}
+		if argType := info.TypeOf(arg); argType != nil {
+			markType(argType, reason, methods, ctx, make(map[types.Type]bool))
+		}
+	}
+}
+
+//markType marks all exported fields of the type and types of the
+//fields. If methods is true, then methods are marked as well.
+//Aliases are resolved to the types that they are referring to.
+func markType(t types.Type, reason string, methods bool, ctx *context, visited map[types.Type]bool) {
+	t = types.Unalias(t)
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+
+	switch t := t.(type) {
+	case *types.Pointer:
+		markType(t.Elem(), reason, methods, ctx, visited)
+	case *types.Slice:
+		markType(t.Elem(), reason, methods, ctx, visited)
+	case *types.Array:
+		markType(t.Elem(), reason, methods, ctx, visited)
+	case *types.Map:
+		markType(t.Key(), reason, methods, ctx, visited)
+		markType(t.Elem(), reason, methods, ctx, visited)
+	case *types.Named:
+		if methods {
+			methodSet := types.NewMethodSet(types.NewPointer(t))
+			for i := 0; i < methodSet.Len(); i++ {
+				if m := methodSet.At(i).Obj(); m.Exported() {
+					markReflection(ctx.defs[objectID(m, ctx, false)], reason)
+				}
+			}
+		}
+		markType(t.Underlying(), reason, methods, ctx, visited)
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			field := t.Field(i)
+			if field.Exported() {
+				markReflection(ctx.defs[objectID(field, ctx, false)], reason)
+			}
+			markType(field.Type(), reason, methods, ctx, visited)
+		}
+	}
+}
+
+func markReflection(def *Definition, reason string) {
+	if def == nil {
+		return
+	}
+	//The first reason in alphabetical order is kept
+	//to have the same result on each run.
+	if len(def.Reflection) == 0 || reason < def.Reflection {
+		util.Debug("definition [%s] is reachable through reflection: %s", def.Name, reason)
+		def.Reflection = reason
+	}
+}
+
+func isEmptyInterface(t types.Type) bool {
+	i, ok := t.Underlying().(*types.Interface)
+	return ok && i.NumMethods() == 0
+}
@@ -0,0 +1,399 @@
+package gounexport
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/dooman87/gounexport/fs"
+	"github.com/dooman87/gounexport/util"
+)
+
+//Remove deletes declaration of definition with its doc comment.
+//Functions, methods, types, vars, consts, struct fields and interface
+//methods could be removed. Usages are not checked, so definition should
+//be dead (see FindDeadDefinitions) or all its usages should be removed
+//as well. It won't remove definition that is declared together with
+//other names (var a, b int) or const that is declared in a group
+//with iota, cause values of other consts would be changed.
+//removeFunc has the same arguments as renameFunc of Unexport, the
+//declaration is replaced by empty string. Use TidyFile to remove
+//imports that are not used after removing.
+func Remove(def *Definition, removeFunc func(string, int, string, string) error) error {
+	if len(def.File) == 0 {
+		return fmt.Errorf("can't remove %s, because its file is unknown", def.Name)
+	}
+	util.Info("removing %s in %s:%d:%d", def.SimpleName, def.File, def.Line, def.Col)
+
+	content, err := ioutil.ReadFile(def.File)
+	if err != nil {
+		return err
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, def.File, content, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	start, end, err := declarationRange(def, f, fset)
+	if err != nil {
+		return err
+	}
+	start, end = lineRange(content, start, end)
+	return removeFunc(def.File, start, string(content[start:end]), "")
+}
+
+//RemoveDefinitions calls Remove for each definition. Fields and interface
+//methods are skipped if the declaration that encloses them (e.g. the type
+//or the struct field of anonymous struct type) is removed, because they
+//are removed together with it and removed ranges can't overlap.
+//Returns errors of definitions that can't be removed.
+func RemoveDefinitions(defs []*Definition, removeFunc func(string, int, string, string) error) map[*Definition]error {
+	sorted := make([]*Definition, len(defs))
+	copy(sorted, defs)
+	//Enclosing declarations have shorter IDs, so they are removed first
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.Count(sorted[i].ID, ".") < strings.Count(sorted[j].ID, ".")
+	})
+
+	errs := make(map[*Definition]error)
+	removed := make(map[string]bool)
+	for _, def := range sorted {
+		if enclosing := enclosingID(def, removed); len(enclosing) > 0 {
+			util.Debug("skipping %s, because it's removed with %s", def.Name, enclosing)
+			continue
+		}
+		if err := Remove(def, removeFunc); err != nil {
+			errs[def] = err
+			continue
+		}
+		removed[def.ID] = true
+	}
+	return errs
+}
+
+//enclosingID returns ID of the removed definition that encloses
+//the declaration of field or interface method. Returns empty
+//string if there is no such definition.
+func enclosingID(def *Definition, removed map[string]bool) string {
+	if def.Kind != KindField && def.Kind != KindInterfaceMethod {
+		return ""
+	}
+	id := def.ID
+	for i := strings.LastIndex(id, "."); i > strings.Index(id, "#"); i = strings.LastIndex(id, ".") {
+		id = id[:i]
+		if removed[id] {
+			return id
+		}
+	}
+	return ""
+}
+
+//declarationRange returns offsets of the declaration of definition
+//including doc comment and comment at the end of the line.
+func declarationRange(def *Definition, f *ast.File, fset *token.FileSet) (int, int, error) {
+	offset := func(pos token.Pos) int {
+		return fset.Position(pos).Offset
+	}
+	isDef := func(ident *ast.Ident) bool {
+		return ident != nil && offset(ident.Pos()) == def.Offset
+	}
+	nodeRange := func(doc *ast.CommentGroup, node ast.Node, comment *ast.CommentGroup) (int, int, error) {
+		start, end := node.Pos(), node.End()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		if comment == nil {
+			comment = lineComment(f, fset, end)
+		}
+		if comment != nil && comment.End() > end {
+			end = comment.End()
+		}
+		return offset(start), offset(end), nil
+	}
+
+	for _, decl := range f.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if isDef(decl.Name) {
+				return nodeRange(decl.Doc, decl, nil)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				var names []*ast.Ident
+				var doc, comment *ast.CommentGroup
+				switch spec := spec.(type) {
+				case *ast.TypeSpec:
+					names, doc, comment = []*ast.Ident{spec.Name}, spec.Doc, spec.Comment
+				case *ast.ValueSpec:
+					names, doc, comment = spec.Names, spec.Doc, spec.Comment
+				}
+				found := false
+				for _, name := range names {
+					found = found || isDef(name)
+				}
+				if !found {
+					continue
+				}
+				if len(names) > 1 {
+					return 0, 0, fmt.Errorf("can't remove %s, because it's declared together with other names", def.Name)
+				}
+				if decl.Tok == token.CONST && len(decl.Specs) > 1 && hasIota(decl) {
+					return 0, 0, fmt.Errorf("can't remove %s, because it's declared in a group with iota", def.Name)
+				}
+				if len(decl.Specs) == 1 {
+					return nodeRange(decl.Doc, decl, comment)
+				}
+				return nodeRange(doc, spec, comment)
+			}
+		}
+	}
+
+	//Fields and interface methods could be declared in
+	//any type, so searching them in all declarations
+	var field *ast.Field
+	ast.Inspect(f, func(node ast.Node) bool {
+		if fieldNode, ok := node.(*ast.Field); ok && field == nil {
+			for _, name := range fieldNode.Names {
+				if isDef(name) {
+					field = fieldNode
+				}
+			}
+		}
+		return field == nil
+	})
+	if field != nil {
+		if len(field.Names) > 1 {
+			return 0, 0, fmt.Errorf("can't remove %s, because it's declared together with other names", def.Name)
+		}
+		return nodeRange(field.Doc, field, field.Comment)
+	}
+	return 0, 0, fmt.Errorf("can't find declaration of %s in %s", def.Name, def.File)
+}
+
+//lineComment returns comment that is started at the same line as pos
+func lineComment(f *ast.File, fset *token.FileSet, pos token.Pos) *ast.CommentGroup {
+	line := fset.Position(pos).Line
+	for _, cg := range f.Comments {
+		if cg.Pos() >= pos && fset.Position(cg.Pos()).Line == line {
+			return cg
+		}
+	}
+	return nil
+}
+
+func hasIota(decl *ast.GenDecl) bool {
+	found := false
+	ast.Inspect(decl, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok && ident.Name == "iota" {
+			found = true
+		}
+		if spec, ok := node.(*ast.ValueSpec); ok && len(spec.Values) == 0 {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+//lineRange extends range to whole lines if there is nothing else
+//on the first and the last lines. If the range is surrounded by blank
+//lines, then the blank line after it is removed as well.
+func lineRange(content []byte, start int, end int) (int, int) {
+	lineStart := start
+	for lineStart > 0 && (content[lineStart-1] == ' ' || content[lineStart-1] == '\t') {
+		lineStart--
+	}
+	lineEnd := end
+	for lineEnd < len(content) && (content[lineEnd] == ' ' || content[lineEnd] == '\t' || content[lineEnd] == '\r') {
+		lineEnd++
+	}
+	if (lineStart > 0 && content[lineStart-1] != '\n') || (lineEnd < len(content) && content[lineEnd] != '\n') {
+		return start, end
+	}
+	if lineEnd < len(content) {
+		lineEnd++
+	}
+
+	//Only the blank line after the range is removed, so
+	//ranges of adjacent declarations are not overlapped
+	blankBefore := lineStart == 0 || (lineStart > 1 && content[lineStart-2] == '\n')
+	if blankBefore && lineEnd < len(content) && content[lineEnd] == '\n' {
+		lineEnd++
+	}
+	return lineStart, lineEnd
+}
+
+//TidyFile removes imports that were used in original content, but
+//they are not used in changed one, and empty declaration groups, e.g.
+//var (). The result is formatted by gofmt if original content was
+//formatted. TidyFile has the signature of edit.Formatter, so it
+//could be added to edit.Set to tidy files after removing.
+func TidyFile(file string, original []byte, changed []byte) ([]byte, error) {
+	if !strings.HasSuffix(file, ".go") {
+		return changed, nil
+	}
+	fset := token.NewFileSet()
+	originalFile, err := parser.ParseFile(fset, file, original, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parser.ParseFile(fset, file, changed, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := newPackageNameImporter()
+	usedBefore, _ := usedImports(originalFile, fset, imp)
+	usedAfter, imported := usedImports(f, fset, imp)
+	type byteRange struct {
+		start int
+		end   int
+	}
+	var ranges []byteRange
+	remove := func(doc *ast.CommentGroup, node ast.Node) {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		end := node.End()
+		if comment := lineComment(f, fset, end); comment != nil {
+			end = comment.End()
+		}
+		s, e := lineRange(changed, fset.Position(start).Offset, fset.Position(end).Offset)
+		ranges = append(ranges, byteRange{s, e})
+	}
+
+	for _, decl := range f.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		if genDecl.Tok != token.IMPORT {
+			if len(genDecl.Specs) == 0 {
+				remove(genDecl.Doc, genDecl)
+			}
+			continue
+		}
+		var unused []*ast.ImportSpec
+		for _, spec := range genDecl.Specs {
+			importSpec := spec.(*ast.ImportSpec)
+			pkgName := imported[importSpec]
+			if pkgName == nil || pkgName.Name() == "_" || pkgName.Name() == "." {
+				continue
+			}
+			if path := pkgName.Imported().Path(); usedBefore[path] && !usedAfter[path] {
+				util.Info("removing unused import %s in %s", importSpec.Path.Value, file)
+				unused = append(unused, importSpec)
+			}
+		}
+		if len(unused) > 0 && len(unused) == len(genDecl.Specs) {
+			remove(genDecl.Doc, genDecl)
+			continue
+		}
+		for _, spec := range unused {
+			remove(spec.Doc, spec)
+		}
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start > ranges[j].start
+	})
+	result := changed
+	for _, r := range ranges {
+		result = append(result[0:r.start:r.start], result[r.end:]...)
+	}
+
+	//Declarations at the end of file are leaving blank lines
+	if bytes.HasSuffix(result, []byte("\n\n")) {
+		result = append(bytes.TrimRight(result, "\n"), '\n')
+	}
+
+	if formatted, err := format.Source(original); err != nil || !bytes.Equal(formatted, original) {
+		util.Debug("%s is not formatted, skipping gofmt", file)
+		return result, nil
+	}
+	return format.Source(result)
+}
+
+//usedImports type checks the file alone and returns paths of imported
+//packages that are used in the file and names of imports. Type errors
+//are ignored, cause other files of the package are not checked. Imports
+//that can't be resolved are not returned, so they are never removed.
+func usedImports(f *ast.File, fset *token.FileSet, imp *packageNameImporter) (map[string]bool, map[*ast.ImportSpec]*types.PkgName) {
+	info := &types.Info{
+		Defs:      make(map[*ast.Ident]types.Object),
+		Uses:      make(map[*ast.Ident]types.Object),
+		Implicits: make(map[ast.Node]types.Object),
+	}
+	conf := types.Config{
+		Importer:    imp,
+		FakeImportC: true,
+		Error:       func(err error) {},
+	}
+	conf.Check(f.Name.Name, fset, []*ast.File{f}, info)
+
+	used := make(map[string]bool)
+	for _, obj := range info.Uses {
+		if pkgName, ok := obj.(*types.PkgName); ok {
+			used[pkgName.Imported().Path()] = true
+		}
+	}
+	imported := make(map[*ast.ImportSpec]*types.PkgName)
+	for _, spec := range f.Imports {
+		var obj types.Object
+		if spec.Name != nil {
+			obj = info.Defs[spec.Name]
+		} else {
+			obj = info.Implicits[spec]
+		}
+		pkgName, ok := obj.(*types.PkgName)
+		if ok && imp.resolved[pkgName.Imported().Path()] {
+			imported[spec] = pkgName
+		}
+	}
+	return used, imported
+}
+
+//packageNameImporter imports packages without their content. Only
+//the name of package is read from its source files, that is enough
+//to resolve names of imported packages in the file.
+type packageNameImporter struct {
+	packages map[string]*types.Package
+	resolved map[string]bool
+}
+
+func newPackageNameImporter() *packageNameImporter {
+	imp := new(packageNameImporter)
+	imp.packages = make(map[string]*types.Package)
+	imp.resolved = make(map[string]bool)
+	return imp
+}
+
+//Import returns empty package with the name from the package clause
+func (imp *packageNameImporter) Import(importPath string) (*types.Package, error) {
+	if pkg, ok := imp.packages[importPath]; ok {
+		return pkg, nil
+	}
+	dir, err := fs.PackageDir(importPath)
+	if err != nil {
+		return nil, err
+	}
+	buildPkg, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	pkg := types.NewPackage(importPath, buildPkg.Name)
+	pkg.MarkComplete()
+	imp.packages[importPath] = pkg
+	imp.resolved[importPath] = true
+	return pkg, nil
+}
@@ -0,0 +1,159 @@
+package gounexport_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dooman87/gounexport"
+	"github.com/dooman87/gounexport/edit"
+)
+
+const removedFile = `package testremove
+
+import (
+	"fmt"
+)
+
+//Used is used by main package
+func Used() {
+	fmt.Println(used)
+}
+
+var used = "used"
+
+var (
+	liveVar = 2
+)
+
+const (
+	liveConst = "live"
+)
+
+//modeB can't be removed, because modes are declared with iota
+const (
+	modeA = iota
+	modeB
+)
+
+//y can't be removed, because it's declared together with x
+var x, y = 1, 2
+
+//Config is used by main package
+type Config struct {
+	//Name is used
+	Name string
+	a, b        int
+}
+
+func (c *Config) String() string {
+	return c.Name + liveConst + fmt.Sprint(liveVar, c.a, c.b, modeA, x)
+}
+`
+
+func TestRemove(t *testing.T) {
+	removepkg := pkg + "/testremove"
+	_, fset, info := parsePackage(removepkg, t)
+	defs := gounexport.GetDefinitions(info, fset)
+
+	opts := new(gounexport.Options)
+	opts.Transitive = true
+	deadDefs := gounexport.FindDeadDefinitions([]string{removepkg}, defs, opts)
+	if len(deadDefs) != 14 {
+		t.Errorf("expected 14 dead definitions, but found %d", len(deadDefs))
+	}
+
+	set := edit.NewSet()
+	set.AddFormatter(gounexport.TidyFile)
+	var failed []string
+	for def := range gounexport.RemoveDefinitions(deadDefs, set.Add) {
+		failed = append(failed, def.SimpleName)
+	}
+	if len(failed) != 2 {
+		t.Errorf("expected modeB and y to be not removed, but found %v", failed)
+	}
+
+	changed, err := set.Apply()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(changed) != 1 {
+		t.Fatalf("expected 1 changed file, but found %d", len(changed))
+	}
+	for file, content := range changed {
+		if !strings.HasSuffix(file, "testremove.go") {
+			t.Errorf("expected testremove.go to be changed, but found %s", file)
+		}
+		if string(content) != removedFile {
+			t.Errorf("expected \n[%s], but found\n[%s]", removedFile, string(content))
+		}
+	}
+}
+
+func TestTidyFile(t *testing.T) {
+	original := `package foo
+
+import (
+	"fmt"
+	_ "net/http/pprof"
+	str "strings"
+
+	"github.com/dooman87/gounexport/testdata/unimported"
+	"gopkg.in/yaml.v2"
+)
+
+var _ = fmt.Sprint(str.ToUpper("a"))
+
+func decode() {
+	yaml.Unmarshal(nil, nil)
+	testunimported.NeverImported()
+}
+`
+	changed := strings.Replace(original, `
+func decode() {
+	yaml.Unmarshal(nil, nil)
+	testunimported.NeverImported()
+}
+`, "", 1)
+	changed = strings.Replace(changed, "str.ToUpper(\"a\")", "\"a\"", 1)
+	expected := `package foo
+
+import (
+	"fmt"
+	_ "net/http/pprof"
+)
+
+var _ = fmt.Sprint("a")
+`
+
+	result, err := gounexport.TidyFile("foo.go", []byte(original), []byte(changed))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if string(result) != expected {
+		t.Errorf("expected \n[%s], but found\n[%s]", expected, string(result))
+	}
+}
+
+func TestTidyFileUnresolvedImport(t *testing.T) {
+	original := `package foo
+
+import "example.com/missing"
+
+func decode() {
+	missing.Decode()
+}
+`
+	changed := `package foo
+
+import "example.com/missing"
+`
+
+	//Name of the package is unknown, so the import is kept
+	result, err := gounexport.TidyFile("foo.go", []byte(original), []byte(changed))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if string(result) != changed {
+		t.Errorf("expected \n[%s], but found\n[%s]", changed, string(result))
+	}
+}
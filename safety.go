@@ -0,0 +1,210 @@
+package gounexport
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+)
+
+//checkRename returns error if definition can't be renamed to newName,
+//because the new name would conflict with other identifiers:
+// - newName is a Go keyword
+// - there is already an object with the same name in the scope of definition
+// - package level definition would shadow predeclared identifier (string, len)
+//   or name of imported package in any file of the package
+// - newName is already declared in a scope between usage and definition,
+//   so the usage would refer to other object
+// - field or method with the same name is in the method set of the type
+//   or of any type of the package that embeds it, including anonymous structs
+//Definitions without type information (e.g. loaded from cache)
+//are checked only for keywords. pkgTypes returns types of the package
+//that could embed the type of field or method, see packageTypes.
+func checkRename(def *Definition, newName string, pkgTypes func(*types.Package) []types.Type) error {
+	if token.Lookup(newName).IsKeyword() {
+		return fmt.Errorf("can't rename %s to %s, because it's a keyword", def.Name, newName)
+	}
+	if def.obj == nil {
+		return nil
+	}
+
+	switch def.Kind {
+	case KindField, KindMethod, KindInterfaceMethod:
+		return checkMemberRename(def, newName, pkgTypes)
+	}
+
+	obj := def.obj
+	parent := obj.Parent()
+	if parent == nil {
+		return nil
+	}
+	if conflict := parent.Lookup(newName); conflict != nil && conflict != obj {
+		return renameConflict(def, newName, conflict)
+	}
+
+	if obj.Pkg() != nil && parent == obj.Pkg().Scope() {
+		if conflict := types.Universe.Lookup(newName); conflict != nil {
+			return fmt.Errorf("can't rename %s to %s, because it would shadow predeclared %s", def.Name, newName, newName)
+		}
+		//File scopes contain imported packages
+		for i := 0; i < parent.NumChildren(); i++ {
+			if conflict := parent.Child(i).Lookup(newName); conflict != nil {
+				return renameConflict(def, newName, conflict)
+			}
+		}
+	}
+
+	for _, u := range def.Usages {
+		if u.scope == nil || len(u.Evidence) > 0 {
+			continue
+		}
+		if _, conflict := u.scope.LookupParent(newName, u.ident); conflict != nil && conflict != obj {
+			return fmt.Errorf("can't rename %s to %s, because %s is declared at %s:%d:%d",
+				def.Name, newName, newName, u.Pos.Filename, u.Pos.Line, u.Pos.Column)
+		}
+	}
+	return nil
+}
+
+//checkMemberRename checks that there are no other fields or methods
+//with the new name in the method set of the type of field or method.
+//Types that embed it are checked as well, because their own fields and
+//methods would shadow the promoted one. Other packages can't declare
+//members with the same unexported name, so only the package is checked.
+func checkMemberRename(def *Definition, newName string, pkgTypes func(*types.Package) []types.Type) error {
+	owner := memberOwner(def)
+	if owner == nil {
+		return nil
+	}
+	pkg := def.obj.Pkg()
+	if conflict := lookupMember(owner, pkg, newName); conflict != nil && conflict != def.obj {
+		return renameConflict(def, newName, conflict)
+	}
+	if pkg == nil {
+		return nil
+	}
+	for _, t := range pkgTypes(pkg) {
+		if types.Identical(t, owner) || lookupMember(t, pkg, def.SimpleName) != def.obj {
+			continue
+		}
+		if conflict := lookupMember(t, pkg, newName); conflict != nil && conflict != def.obj {
+			return fmt.Errorf("can't rename %s to %s, because it would be shadowed by %s in %s",
+				def.Name, newName, conflict, t)
+		}
+	}
+	return nil
+}
+
+//lookupMember returns field or method of type by name. Methods
+//with pointer receivers are found as well. Members of generic
+//types are returned as declared, not instantiated.
+func lookupMember(t types.Type, pkg *types.Package, name string) types.Object {
+	if !types.IsInterface(t) {
+		if _, ok := t.(*types.Pointer); !ok {
+			t = types.NewPointer(t)
+		}
+	}
+	obj, _, _ := types.LookupFieldOrMethod(t, true, pkg, name)
+	switch obj := obj.(type) {
+	case *types.Func:
+		return obj.Origin()
+	case *types.Var:
+		return obj.Origin()
+	}
+	return obj
+}
+
+//packageTypes returns named types of the package and anonymous
+//structs and interfaces that are used in its declarations, e.g.
+//var v struct{ T }. Local declarations are included.
+func packageTypes(pkg *types.Package) []types.Type {
+	var result []types.Type
+	seen := make(map[types.Type]bool)
+	var addType func(t types.Type)
+	addType = func(t types.Type) {
+		if t == nil || seen[t] {
+			return
+		}
+		seen[t] = true
+		switch t := t.(type) {
+		case *types.Named:
+			if t.Obj().Pkg() == pkg {
+				result = append(result, t)
+				addType(t.Underlying())
+			}
+		case *types.Struct:
+			result = append(result, t)
+			for i := 0; i < t.NumFields(); i++ {
+				addType(t.Field(i).Type())
+			}
+		case *types.Interface:
+			result = append(result, t)
+		case *types.Pointer:
+			addType(t.Elem())
+		case *types.Slice:
+			addType(t.Elem())
+		case *types.Array:
+			addType(t.Elem())
+		case *types.Chan:
+			addType(t.Elem())
+		case *types.Map:
+			addType(t.Key())
+			addType(t.Elem())
+		case *types.Signature:
+			for _, tuple := range []*types.Tuple{t.Params(), t.Results()} {
+				for i := 0; i < tuple.Len(); i++ {
+					addType(tuple.At(i).Type())
+				}
+			}
+		}
+	}
+	var addScope func(scope *types.Scope)
+	addScope = func(scope *types.Scope) {
+		for _, name := range scope.Names() {
+			addType(scope.Lookup(name).Type())
+		}
+		for i := 0; i < scope.NumChildren(); i++ {
+			addScope(scope.Child(i))
+		}
+	}
+	addScope(pkg.Scope())
+	return result
+}
+
+//memberOwner returns type that declares field or method of definition
+func memberOwner(def *Definition) types.Type {
+	switch obj := def.obj.(type) {
+	case *types.Func:
+		sig, ok := obj.Type().(*types.Signature)
+		if !ok || sig.Recv() == nil {
+			return nil
+		}
+		recv := sig.Recv().Type()
+		if ptr, ok := recv.(*types.Pointer); ok {
+			recv = ptr.Elem()
+		}
+		return recv
+	case *types.Var:
+		if obj.Pkg() == nil {
+			return nil
+		}
+		scope := obj.Pkg().Scope()
+		for _, name := range scope.Names() {
+			typeName, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			if s, ok := typeName.Type().Underlying().(*types.Struct); ok {
+				for i := 0; i < s.NumFields(); i++ {
+					if s.Field(i) == obj {
+						return typeName.Type()
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func renameConflict(def *Definition, newName string, conflict types.Object) error {
+	return fmt.Errorf("can't rename %s to %s, because it conflicts with %s", def.Name, newName, conflict)
+}
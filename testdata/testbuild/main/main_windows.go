@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dooman87/gounexport/testdata/testbuild"
+)
+
+func init() {
+	fmt.Println(testbuild.WindowsHelper())
+}
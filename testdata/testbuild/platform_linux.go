@@ -0,0 +1,6 @@
+package testbuild
+
+//Platform is defined for each platform separately
+func Platform() string {
+	return "linux"
+}
@@ -0,0 +1,8 @@
+//go:build integration
+
+package testbuild
+
+//Integration is an example of function defined only with integration tag
+func Integration() string {
+	return "integration"
+}
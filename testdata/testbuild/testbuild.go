@@ -0,0 +1,16 @@
+package testbuild
+
+//Common is an example of function used on all platforms
+func Common() string {
+	return "common"
+}
+
+//WindowsHelper is an example of function used only on windows
+func WindowsHelper() string {
+	return "windows helper"
+}
+
+//Unused is an example of function unused on all platforms
+func Unused() string {
+	return Common()
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dooman87/gounexport/testdata/testcollision"
+)
+
+func main() {
+	fmt.Println(testcollision.Config.Port)
+}
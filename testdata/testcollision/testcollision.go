@@ -0,0 +1,10 @@
+package testcollision
+
+//Port is not used outside of the package
+var Port = 80
+
+//Config is a variable of anonymous struct. Its field
+//has the same full name as Port variable.
+var Config struct {
+	Port int
+}
@@ -0,0 +1,5 @@
+package testcontexts
+
+//B is declared only on linux. It's sorted before A, so
+//it shouldn't take identifier of A in other contexts.
+func (s *Service) B() {}
@@ -0,0 +1,8 @@
+package main
+
+import "github.com/dooman87/gounexport/testdata/testcontexts"
+
+func main() {
+	s := new(testcontexts.Service)
+	s.C()
+}
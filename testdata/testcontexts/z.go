@@ -0,0 +1,10 @@
+package testcontexts
+
+//Service has methods that are declared in different files
+type Service struct{}
+
+//A is declared on all platforms, but not used
+func (s *Service) A() {}
+
+//C is used by main
+func (s *Service) C() {}
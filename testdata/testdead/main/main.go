@@ -0,0 +1,12 @@
+package main
+
+import "github.com/dooman87/gounexport/testdata/testdead"
+
+func main() {
+	var p testdead.Printer = &testdead.Console{Prefix: "> "}
+	p.Print("hello")
+	testdead.Used()
+}
+
+//Exported is not used and main package has no API
+func Exported() {}
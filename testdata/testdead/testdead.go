@@ -0,0 +1,53 @@
+package testdead
+
+import "fmt"
+
+//Printer is implemented by Console
+type Printer interface {
+	Print(s string)
+}
+
+//Console prints to stdout
+type Console struct {
+	//Prefix is added to each line
+	Prefix      string
+	unusedField int
+}
+
+//Print implements Printer, so it's not dead
+func (c *Console) Print(s string) {
+	fmt.Println(c.Prefix + s)
+}
+
+//Used is used by main package
+func Used() {
+	helper()
+}
+
+func helper() {
+}
+
+//Live is not used anywhere, but it's API of the library
+func Live() {}
+
+//Unused is not used anywhere
+func Unused() {
+	onlyFromUnused()
+}
+
+//onlyFromUnused is used only by dead code
+func onlyFromUnused() {
+	deeper(unusedConst)
+}
+
+func deeper(n int) {
+	deeper(n - 1)
+}
+
+const unusedConst = 1
+
+var unusedVar = 2
+
+func init() {
+	fmt.Println("init")
+}
@@ -0,0 +1,47 @@
+package testdynamic
+
+import (
+	"os"
+	"reflect"
+	"text/template"
+)
+
+//Greeter has methods and fields that are used dynamically
+type Greeter struct {
+	//Name is used in greeting.tmpl
+	Name string
+	//Greeting is used in template string
+	Greeting bool
+	//Hidden is used by reflection
+	Hidden string
+}
+
+//Hello is called by reflection
+func (g *Greeter) Hello() string {
+	return "Hello"
+}
+
+//Bye is called from template string
+func (g *Greeter) Bye() string {
+	return "Bye"
+}
+
+//Unused is not used
+func (g *Greeter) Unused() {
+}
+
+//Greet is used by main package
+func Greet(v interface{}) error {
+	value := reflect.ValueOf(v)
+	value.MethodByName("Hello").Call(nil)
+	value.Elem().FieldByName("Hidden").SetString("")
+
+	t, err := template.New("greeting.tmpl").ParseFiles("greeting.tmpl")
+	if err != nil {
+		return err
+	}
+	if err := t.Execute(os.Stdout, v); err != nil {
+		return err
+	}
+	return template.Must(template.New("bye").Parse(`{{if .Greeting}}{{.Bye}}{{end}}`)).Execute(os.Stdout, v)
+}
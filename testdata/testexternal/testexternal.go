@@ -0,0 +1,12 @@
+package testexternal
+
+//UsedInTest is an example of function that is used
+//only in external test package
+func UsedInTest() string {
+	return "I'm used in test"
+}
+
+//Unused is an example of unused function
+func Unused() string {
+	return UsedInTest()
+}
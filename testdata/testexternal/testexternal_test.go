@@ -0,0 +1,13 @@
+package testexternal_test
+
+import (
+	"testing"
+
+	"github.com/dooman87/gounexport/testdata/testexternal"
+)
+
+func TestUsedInTest(t *testing.T) {
+	if len(testexternal.UsedInTest()) == 0 {
+		t.Error("expected not empty string")
+	}
+}
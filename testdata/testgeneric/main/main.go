@@ -0,0 +1,14 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dooman87/gounexport/testdata/testgeneric"
+)
+
+func main() {
+	l := new(testgeneric.List[int])
+	l.Push(1)
+	var g testgeneric.Getter[string] = testgeneric.Box[string]{}
+	fmt.Println(l.Items, testgeneric.Sum(1, 2), g.Get())
+}
@@ -0,0 +1,53 @@
+package testgeneric
+
+//Number is a constraint that is used only in the package
+type Number interface {
+	~int | ~float64
+}
+
+//List is a generic type that is instantiated in main package
+type List[T any] struct {
+	//Items is used by main package
+	Items []T
+	//UnusedField is not used
+	UnusedField T
+}
+
+//Push is called on instantiated list
+func (l *List[T]) Push(v T) {
+	l.Items = append(l.Items, v)
+}
+
+//Len is not used
+func (l *List[T]) Len() int {
+	return len(l.Items)
+}
+
+//Sum is a generic function with constraint
+func Sum[T Number](values ...T) T {
+	var result T
+	for _, v := range values {
+		result += v
+	}
+	return result
+}
+
+//UnusedFunc is a generic function that is not used
+func UnusedFunc[T any](v T) T {
+	return v
+}
+
+//Getter is a generic interface
+type Getter[T any] interface {
+	Get() T
+}
+
+//Box implements Getter
+type Box[T any] struct {
+	Value T
+}
+
+//Get is used through Getter interface
+func (b Box[T]) Get() T {
+	return b.Value
+}
@@ -0,0 +1,7 @@
+//gounexport:keep
+
+package testkeep
+
+//KeptByFile is kept by directive of the file
+func KeptByFile() {
+}
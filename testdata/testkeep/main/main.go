@@ -0,0 +1,12 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dooman87/gounexport/testdata/testkeep"
+)
+
+func main() {
+	fmt.Println(new(testkeep.Config))
+	testkeep.Used()
+}
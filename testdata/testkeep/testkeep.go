@@ -0,0 +1,30 @@
+package testkeep
+
+//Plugin is looked up by name, so it's kept exported
+//gounexport:keep plugin entry point
+func Plugin() {
+}
+
+//Kept variables are kept by directive of the group
+//gounexport:keep
+var (
+	KeptVar  = 1
+	KeptVar2 = 2
+)
+
+//Config is used by main package
+type Config struct {
+	//Name is set by reflection
+	Name string //gounexport:keep
+	//UnusedField is not used
+	UnusedField string
+}
+
+//Used is used by main package, so directive is stale
+//gounexport:keep
+func Used() {
+}
+
+//Unused is not used and not kept
+func Unused() {
+}
@@ -0,0 +1,6 @@
+package dep
+
+//Helper is used by the module that replaces this dependency
+func Helper() string {
+	return "help"
+}
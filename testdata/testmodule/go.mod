@@ -0,0 +1,10 @@
+module example.com/testmodule
+
+go 1.16
+
+require (
+	example.com/dep v1.0.0
+	github.com/BurntSushi/toml v0.3.1 // indirect
+)
+
+replace example.com/dep => ./dep
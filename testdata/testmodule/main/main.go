@@ -0,0 +1,11 @@
+package main
+
+import (
+	"fmt"
+
+	"example.com/testmodule"
+)
+
+func main() {
+	fmt.Println(testmodule.Used())
+}
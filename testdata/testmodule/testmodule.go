@@ -0,0 +1,15 @@
+package testmodule
+
+import (
+	"example.com/dep"
+)
+
+//Used is an example of function used by another package of the module
+func Used() string {
+	return dep.Helper()
+}
+
+//Unused is an example of unused function in the module
+func Unused() string {
+	return Used()
+}
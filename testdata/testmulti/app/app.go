@@ -0,0 +1,9 @@
+package app
+
+import (
+	"github.com/dooman87/gounexport/testdata/testmulti/lib"
+)
+
+func run() {
+	lib.Used()
+}
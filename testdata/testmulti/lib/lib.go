@@ -0,0 +1,9 @@
+package lib
+
+//Used is used by app package
+func Used() {
+}
+
+//Unused is not used by app package
+func Unused() {
+}
@@ -0,0 +1,5 @@
+package libx
+
+//Other is in the package that starts with the path of lib package
+func Other() {
+}
@@ -0,0 +1,32 @@
+package testnaming
+
+//HTTPClient has leading initialism
+type HTTPClient struct{}
+
+//ID is an initialism
+var ID = 1
+
+//HTTP2Server has initialism followed by digit
+func HTTP2Server() {}
+
+//Type would be renamed to keyword, fallback is used
+func Type() {}
+
+//URL is renamed by mapping
+var URL = "http://localhost"
+
+//Port is renamed by mapping with full name
+var Port = 80
+
+//JSONData and JsonData have the same new name
+var JSONData = 1
+
+//JsonData and JSONData have the same new name
+var JsonData = 2
+
+//Limit conflicts with limit and limit_, so the prefix is used
+var Limit = 3
+
+var limit = Limit
+
+var limit_ = limit
@@ -0,0 +1,7 @@
+package main
+
+import "github.com/dooman87/gounexport/testdata/testreach"
+
+func main() {
+	testreach.NewServer().Run()
+}
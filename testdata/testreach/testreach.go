@@ -0,0 +1,58 @@
+package testreach
+
+import "fmt"
+
+//Handler handles requests
+type Handler interface {
+	Handle(req string)
+}
+
+//Server is reachable from main
+type Server struct {
+	handler Handler
+	unused  int
+}
+
+//NewServer is called from main
+func NewServer() *Server {
+	return &Server{handler: new(echo)}
+}
+
+//Run is called from main
+func (s *Server) Run() {
+	s.handler.Handle("ping")
+}
+
+type echo struct{}
+
+//Handle is called through Handler interface
+func (e *echo) Handle(req string) {
+	fmt.Println(format(req))
+}
+
+func format(req string) string {
+	return "> " + req
+}
+
+//ping and pong are calling each other,
+//but nothing is calling them
+func ping(n int) {
+	pong(n - 1)
+}
+
+func pong(n int) {
+	if n > 0 {
+		ping(n)
+	}
+}
+
+//Cluster is exported API, but it's used only by clusterHelper
+type Cluster struct{}
+
+func clusterHelper() *Cluster {
+	return new(Cluster)
+}
+
+var registry = map[string]func(int){
+	"ping": ping,
+}
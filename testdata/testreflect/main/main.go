@@ -0,0 +1,9 @@
+package main
+
+import (
+	"github.com/dooman87/gounexport/testdata/testreflect"
+)
+
+func main() {
+	testreflect.Encode()
+}
@@ -0,0 +1,59 @@
+package testreflect
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"text/template"
+)
+
+//Tagged has fields that are encoded by tags
+type Tagged struct {
+	//Name is used by json encoder
+	Name string `json:"name"`
+	//Ignored is skipped by json encoder
+	Ignored string `json:"-"`
+	//Plain has no tags
+	Plain string
+}
+
+//Encoded is passed to json encoder
+type Encoded struct {
+	Value  int
+	Nested *Nested
+}
+
+//Nested is a type of field of encoded struct
+type Nested struct {
+	Inner []string
+}
+
+//Payload is passed to json encoder by alias
+type Payload struct {
+	Data string
+}
+
+//PayloadAlias is an alias of Payload
+type PayloadAlias = Payload
+
+//View is passed to template
+type View struct {
+	Title string
+}
+
+//Upper is called from template
+func (v *View) Upper() string {
+	return strings.ToUpper(v.Title)
+}
+
+//Encode is used by main package
+func Encode() error {
+	if _, err := json.Marshal(&Encoded{}); err != nil {
+		return err
+	}
+	if _, err := json.Marshal(&PayloadAlias{}); err != nil {
+		return err
+	}
+	t := template.Must(template.New("view").Parse("{{.Title}} {{.Upper}}"))
+	return t.Execute(os.Stdout, &View{})
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dooman87/gounexport/testdata/testremove"
+)
+
+func main() {
+	testremove.Used()
+	fmt.Println((&testremove.Config{Name: "name"}).String())
+}
@@ -0,0 +1,73 @@
+package testremove
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Used is used by main package
+func Used() {
+	fmt.Println(used)
+}
+
+//unused is not used, so it's removed with the comment
+func unused() {
+	fmt.Println(strings.ToUpper("unused"))
+}
+
+var used = "used"
+
+var (
+	//deadVar is removed from the group
+	deadVar = 1
+	liveVar = 2
+)
+
+var (
+	onlyDead = 3
+)
+
+const (
+	deadConst = "dead" //removed with the comment
+	liveConst = "live"
+)
+
+//modeB can't be removed, because modes are declared with iota
+const (
+	modeA = iota
+	modeB
+)
+
+//y can't be removed, because it's declared together with x
+var x, y = 1, 2
+
+//Config is used by main package
+type Config struct {
+	//Name is used
+	Name string
+	//unusedField is removed
+	unusedField int
+	a, b        int
+}
+
+func (c *Config) String() string {
+	return c.Name + liveConst + fmt.Sprint(liveVar, c.a, c.b, modeA, x)
+}
+
+//deadType is removed with its method
+type deadType struct{}
+
+func (d deadType) method() {}
+
+//deadStruct is removed with its fields
+type deadStruct struct {
+	a int
+	b struct {
+		c string
+	}
+}
+
+//deadInterface is removed with its methods
+type deadInterface interface {
+	run()
+}
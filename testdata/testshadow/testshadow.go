@@ -0,0 +1,70 @@
+package testshadow
+
+import "encoding/json"
+
+//Type can't be renamed to keyword
+func Type() {}
+
+//String would shadow predeclared string type
+func String() string {
+	return "string"
+}
+
+//Json would conflict with imported package
+var Json = 1
+
+//Count would be shadowed by local variable in Sum
+var Count = 2
+
+//Total would be shadowed by parameter of Add
+var Total = 3
+
+//Safe could be renamed
+func Safe() int {
+	return Json + Count
+}
+
+//User has a field that would conflict with method
+type User struct {
+	Name string
+}
+
+func (u *User) name() string {
+	return u.Name
+}
+
+//Inner.Foo would be shadowed by foo field of Outer
+type Inner struct{}
+
+func (i *Inner) Foo() int {
+	return 1
+}
+
+//Outer has a field that would shadow promoted method
+type Outer struct {
+	Inner
+	foo int
+}
+
+//Base.Field would be shadowed by field of anonymous struct
+type Base struct {
+	Field int
+}
+
+var anonymous = struct {
+	Base
+	field string
+}{}
+
+func Sum() int {
+	count := 1
+	return count + Count
+}
+
+func Add(total int) int {
+	return total + Total
+}
+
+func encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
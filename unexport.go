@@ -1,7 +1,6 @@
 package gounexport
 
 import (
-	"fmt"
 	"regexp"
 	"strings"
 
@@ -9,20 +8,81 @@ import (
 	"github.com/dooman87/gounexport/util"
 )
 
+//Options tunes the search of unused definitions
+type Options struct {
+	//Excludes is a list of patterns. Definitions with
+	//the names that are matched will be skipped.
+	Excludes []*regexp.Regexp
+	//IgnoreTestUsages makes usages from external test
+	//packages (package foo_test) not count. Be aware, that
+	//tests will be broken after renaming of such definitions.
+	IgnoreTestUsages bool
+	//Directives are used to find definitions that are marked
+	//by KeepDirective. If it's nil, then directives are still
+	//honored, but stale ones can't be retrieved.
+	Directives *Directives
+	//Kinds are kinds of definitions to find.
+	//All kinds are searched if it's empty.
+	Kinds []Kind
+	//IncludeReflected makes definitions that are reachable
+	//through reflection (see Definition.Reflection) reported.
+	//Dynamic usages (see Usage.Evidence) are not counted as well.
+	//Be aware, that unexporting of them breaks encoding.
+	IncludeReflected bool
+	//Transitive makes usages inside of dead functions not
+	//count, see FindDeadDefinitions.
+	Transitive bool
+	//Roots are patterns of full names of definitions that are
+	//roots of reachability, see FindUnreachableDefinitions.
+	Roots []*regexp.Regexp
+	//APIRoots makes exported definitions of packages
+	//that are not main roots of reachability.
+	APIRoots bool
+	//IncludeAPI makes exported definitions of packages that are
+	//not main reported by FindDeadDefinitions. By default, they
+	//are API of the library, that could be used by other projects.
+	IncludeAPI bool
+}
+
 //FindUnusedDefinitions returns list of definitions that could be
 //moved to private e.g. renamed. Criteria for renaming:
 // - Definition should be exported
 // - Definition should be in target package
 // - Definition is not implementing external interfaces
 // - Definition is not used in external packages
+// - Definition is not reachable through reflection (struct tags, encoders, templates)
+// - Definition is not marked by KeepDirective
+//Usages from external test packages are counted.
 func FindUnusedDefinitions(pkg string, defs map[string]*Definition, excludes []*regexp.Regexp) []*Definition {
+	opts := new(Options)
+	opts.Excludes = excludes
+	return FindUnusedDefinitionsWithOptions(pkg, defs, opts)
+}
+
+//FindUnusedDefinitionsWithOptions is the same as FindUnusedDefinitions
+//but allows to tune the search using opts.
+func FindUnusedDefinitionsWithOptions(pkg string, defs map[string]*Definition, opts *Options) []*Definition {
+	return FindUnusedDefinitionsInPackages([]string{pkg}, defs, opts)
+}
+
+//FindUnusedDefinitionsInPackages is the same as FindUnusedDefinitionsWithOptions
+//but definitions are searched in several packages. Usages between
+//these packages are counted as external.
+func FindUnusedDefinitionsInPackages(pkgs []string, defs map[string]*Definition, opts *Options) []*Definition {
+	directives := opts.Directives
+	if directives == nil {
+		directives = NewDirectives()
+	}
+
 	var unused []*Definition
 	for _, def := range defs {
-		if !def.Exported {
+		if !def.Exported || isTestPackage(def) || !isInPackages(def, pkgs) {
 			continue
 		}
+		//Loading all files of packages to find stale directives
+		directives.load(def.File)
 
-		if strings.HasPrefix(def.Name, pkg) && !isExcluded(def, excludes) && !isUsed(def) {
+		if !isUsed(def, opts) && !isReflected(def, opts) && !directives.keeps(def) && !isExcluded(def, opts.Excludes) && hasKind(def, opts.Kinds) {
 			util.Info("adding [%s] to unexport list", def.Name)
 			unused = append(unused, def)
 		}
@@ -31,6 +91,48 @@ func FindUnusedDefinitions(pkg string, defs map[string]*Definition, excludes []*
 	return unused
 }
 
+//isInPackages returns true if definition is in one of pkgs
+//or in a package inside of them. Local definitions and type
+//parameters are skipped, because their names are not qualified.
+func isInPackages(def *Definition, pkgs []string) bool {
+	if def.Pkg != nil && !isPackageIn(def.Pkg.Path(), pkgs) {
+		return false
+	}
+	for _, pkg := range pkgs {
+		if strings.HasPrefix(def.Name, pkg+".") || strings.HasPrefix(def.Name, pkg+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+//isTestPackage returns true if definition is declared in
+//external test package. There is no reason to unexport them.
+func isTestPackage(def *Definition) bool {
+	return def.Pkg != nil && strings.HasSuffix(def.Pkg.Path(), "_test")
+}
+
+func isReflected(def *Definition, opts *Options) bool {
+	if len(def.Reflection) == 0 || opts.IncludeReflected {
+		return false
+	}
+	util.Info("definition [%s] skipped, because it's reachable through reflection: %s", def.Name, def.Reflection)
+	return true
+}
+
+func hasKind(def *Definition, kinds []Kind) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, kind := range kinds {
+		if def.Kind == kind {
+			return true
+		}
+	}
+	util.Info("definition [%s] skipped, because kind [%s] is not in %v", def.Name, def.Kind, kinds)
+	return false
+}
+
 func isExcluded(def *Definition, excludes []*regexp.Regexp) bool {
 	if excludes == nil || len(excludes) == 0 {
 		return false
@@ -45,7 +147,7 @@ func isExcluded(def *Definition, excludes []*regexp.Regexp) bool {
 	return false
 }
 
-func isUsed(def *Definition) bool {
+func isUsed(def *Definition, opts *Options) bool {
 	used := true
 
 	if len(def.Usages) == 0 {
@@ -54,15 +156,30 @@ func isUsed(def *Definition) bool {
 		//Checking pathes of usages to not count internal
 		hasExternalUsages := false
 		util.Debug("checking [%s]", def.Name)
+		pkgPath := ""
+		if def.Pkg != nil {
+			pkgPath = def.Pkg.Path()
+		} else if dotIdx := strings.LastIndex(def.Name, "."); dotIdx >= 0 {
+			pkgPath = def.Name[0:dotIdx]
+		}
 		for _, u := range def.Usages {
-			pkgPath := ""
-			if def.Pkg != nil {
-				pkgPath = def.Pkg.Path()
-			} else if dotIdx := strings.LastIndex(def.Name, "."); dotIdx >= 0 {
-				pkgPath = def.Name[0:dotIdx]
-			}
 			util.Debug("checking [%v]", u.Pos)
-			if u.Pos.IsValid() && fs.GetPackagePath(u.Pos.Filename) != pkgPath {
+			if !u.Pos.IsValid() || (opts.IgnoreTestUsages && u.isTest()) {
+				continue
+			}
+			//Names are resolved at runtime, so package doesn't matter
+			if len(u.Evidence) > 0 {
+				if opts.IncludeReflected {
+					continue
+				}
+				hasExternalUsages = true
+				break
+			}
+			usagePkg := u.Pkg
+			if len(usagePkg) == 0 {
+				usagePkg = fs.GetPackagePath(u.Pos.Filename)
+			}
+			if usagePkg != pkgPath {
 				hasExternalUsages = true
 				break
 			}
@@ -73,7 +190,7 @@ func isUsed(def *Definition) bool {
 	if !used {
 		//Check all interfaces
 		for _, i := range def.Interfaces {
-			if isUsed(i) {
+			if isUsed(i, opts) {
 				used = true
 				break
 			}
@@ -84,28 +201,39 @@ func isUsed(def *Definition) bool {
 
 //Unexport hides definition by changing first letter
 //to lower case. It won't rename if there is already existing
-//unexported symbol with the same name.
+//unexported symbol with the same name or if the new name would
+//shadow or be shadowed by other identifier at any usage, e.g.
+//local variable, parameter, imported package or keyword.
 //renameFunc is a func that accepts four arguments: full path to file,
 //offset in a file to replace, original string, string to replace. It will
 //be called when renaming is possible.
 func Unexport(def *Definition, allDefs map[string]*Definition,
 	renameFunc func(string, int, string, string) error) error {
-	util.Info("unexporting %s in %s:%d:%d", def.SimpleName, def.File, def.Line, def.Col)
-	newName := strings.ToLower(def.SimpleName[0:1]) + def.SimpleName[1:]
+	naming := new(Naming)
+	naming.Strategy = NamingFirstLetter
+	return UnexportWithNaming(def, allDefs, naming, renameFunc)
+}
 
-	//Searching for conflict
-	lastIdx := strings.LastIndex(def.Name, def.SimpleName)
-	newFullName := def.Name[0:lastIdx] + newName + def.Name[lastIdx+len(newName):]
-	if allDefs[newFullName] != nil {
-		return fmt.Errorf("can't unexport %s because it conflicts with existing member", def.Name)
+//UnexportWithNaming is the same as Unexport, but the new name is chosen
+//by naming. If the preferred name conflicts, then fallback names are tried.
+func UnexportWithNaming(def *Definition, allDefs map[string]*Definition, naming *Naming,
+	renameFunc func(string, int, string, string) error) error {
+	util.Info("unexporting %s in %s:%d:%d", def.SimpleName, def.File, def.Line, def.Col)
+	newName, err := naming.newName(def, allDefs)
+	if err != nil {
+		return err
 	}
 
 	//rename definitions and usages
-	err := renameFunc(def.File, def.Offset, def.SimpleName, newName)
+	err = renameFunc(def.File, def.Offset, def.SimpleName, newName)
 	for _, u := range def.Usages {
 		if err != nil {
 			break
 		}
+		//Dynamic usages are pointing to the call or template
+		if len(u.Evidence) > 0 {
+			continue
+		}
 		err = renameFunc(u.Pos.Filename, u.Pos.Offset, def.SimpleName, newName)
 	}
 
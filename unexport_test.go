@@ -2,12 +2,18 @@ package gounexport_test
 
 import (
 	"go/ast"
+	"go/build"
 	"go/types"
+	"io/ioutil"
 	"log"
+	"os"
 	"regexp"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/dooman87/gounexport"
+	"github.com/dooman87/gounexport/fs"
 	"github.com/dooman87/gounexport/util"
 )
 
@@ -43,6 +49,42 @@ func TestGetDefinitionsToHideInterface(t *testing.T) {
 	assertDef("github.com/dooman87/gounexport/testdata/testinterface.UnusedInterface", unusedDefs, t)
 }
 
+func TestGetDefinitionsToHideGeneric(t *testing.T) {
+	genericpkg := pkg + "/testgeneric"
+	unusedDefs := getDefinitionsToHide(genericpkg, 5, t)
+
+	assertDef(genericpkg+".Number", unusedDefs, t)
+	assertDef(genericpkg+".List.UnusedField", unusedDefs, t)
+	assertDef(genericpkg+".List.Len", unusedDefs, t)
+	assertDef(genericpkg+".UnusedFunc", unusedDefs, t)
+	assertDef(genericpkg+".Box.Value", unusedDefs, t)
+
+	//Usage of instantiated method is renamed as well
+	_, fset, info := parsePackage(genericpkg, t)
+	defs := gounexport.GetDefinitions(info, fset)
+	renamesCount := make(map[string]int)
+	renameFunc := func(file string, offset int, source string, target string) error {
+		renamesCount[source] = renamesCount[source] + 1
+		return nil
+	}
+	if err := gounexport.Unexport(gounexport.FindDefinitionByName(defs, genericpkg+".List.Push"), defs, renameFunc); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	assertRename(renamesCount, "Push", 2, t)
+}
+
+func TestGetDefinitionsToHideCollision(t *testing.T) {
+	collisionpkg := pkg + "/testcollision"
+	unusedDefs := getDefinitionsToHide(collisionpkg, 1, t)
+
+	if len(unusedDefs) == 1 && unusedDefs[0].Kind != gounexport.KindVar {
+		t.Errorf("expected Port variable, but found %s %s", unusedDefs[0].Kind, unusedDefs[0].Name)
+	}
+	if len(unusedDefs) == 1 && unusedDefs[0].ID != collisionpkg+"#Port" {
+		t.Errorf("expected ID %s#Port, but found %s", collisionpkg, unusedDefs[0].ID)
+	}
+}
+
 func TestGetDefinitionsToHideExclusions(t *testing.T) {
 	unimportedpkg := pkg + "/testinterface"
 	regex, _ := regexp.Compile("Unused*")
@@ -50,6 +92,371 @@ func TestGetDefinitionsToHideExclusions(t *testing.T) {
 	getDefinitionsToHideWithExclusions(unimportedpkg, 0, excludes, t)
 }
 
+func TestGetDefinitionsToHideExternalTest(t *testing.T) {
+	unimportedpkg := pkg + "/testexternal"
+	unusedDefs := getDefinitionsToHide(unimportedpkg, 1, t)
+
+	assertDef("github.com/dooman87/gounexport/testdata/testexternal.Unused", unusedDefs, t)
+}
+
+func TestGetDefinitionsToHideIgnoreTests(t *testing.T) {
+	unimportedpkg := pkg + "/testexternal"
+	_, fset, info := parsePackage(unimportedpkg, t)
+	defs := gounexport.GetDefinitions(info, fset)
+	opts := new(gounexport.Options)
+	opts.IgnoreTestUsages = true
+	unusedDefs := gounexport.FindUnusedDefinitionsWithOptions(unimportedpkg, defs, opts)
+
+	if len(unusedDefs) != 2 {
+		t.Errorf("expected %d unused exported definitions, but found %d", 2, len(unusedDefs))
+	}
+	assertDef("github.com/dooman87/gounexport/testdata/testexternal.Unused", unusedDefs, t)
+	assertDef("github.com/dooman87/gounexport/testdata/testexternal.UsedInTest", unusedDefs, t)
+}
+
+func TestGetDefinitionsToHideBuildContext(t *testing.T) {
+	unimportedpkg := pkg + "/testbuild"
+	unusedDefs := getDefinitionsToHide(unimportedpkg, 2, t)
+
+	assertDef("github.com/dooman87/gounexport/testdata/testbuild.Unused", unusedDefs, t)
+	assertDef("github.com/dooman87/gounexport/testdata/testbuild.WindowsHelper", unusedDefs, t)
+}
+
+func TestGetDefinitionsToHideBuildContexts(t *testing.T) {
+	unimportedpkg := pkg + "/testbuild"
+	linux := build.Default
+	linux.GOOS, linux.GOARCH = "linux", "amd64"
+	windows := build.Default
+	windows.GOOS, windows.GOARCH = "windows", "amd64"
+
+	defs, err := gounexport.GetDefinitionsWithContexts(unimportedpkg, []*build.Context{&linux, &windows})
+	if err != nil {
+		t.Fatalf("error while parsing package %v", err)
+	}
+	unusedDefs := gounexport.FindUnusedDefinitions(unimportedpkg, defs, nil)
+
+	if len(unusedDefs) != 1 {
+		t.Errorf("expected %d unused exported definitions, but found %d", 1, len(unusedDefs))
+	}
+	assertDef("github.com/dooman87/gounexport/testdata/testbuild.Unused", unusedDefs, t)
+
+	platform := gounexport.FindDefinitionByName(defs, "github.com/dooman87/gounexport/testdata/testbuild.Platform")
+	if platform == nil {
+		t.Fatal("expected Platform definition")
+	}
+	declarations := 0
+	for _, u := range platform.Usages {
+		if strings.HasSuffix(u.Pos.Filename, "platform_windows.go") || strings.HasSuffix(u.Pos.Filename, "platform_linux.go") {
+			declarations++
+		}
+	}
+	if declarations != 1 {
+		t.Errorf("expected declaration from another context in usages, but found %d", declarations)
+	}
+}
+
+func TestGetDefinitionsToHideBuildContextsMethods(t *testing.T) {
+	contextspkg := pkg + "/testcontexts"
+	linux := build.Default
+	linux.GOOS, linux.GOARCH = "linux", "amd64"
+	windows := build.Default
+	windows.GOOS, windows.GOARCH = "windows", "amd64"
+
+	for _, contexts := range [][]*build.Context{{&linux, &windows}, {&windows, &linux}} {
+		defs, err := gounexport.GetDefinitionsWithContexts(contextspkg, contexts)
+		if err != nil {
+			t.Fatalf("error while parsing package %v", err)
+		}
+		unusedDefs := gounexport.FindUnusedDefinitions(contextspkg, defs, nil)
+		if len(unusedDefs) != 2 {
+			t.Errorf("expected %d unused exported definitions, but found %d", 2, len(unusedDefs))
+		}
+		assertDef(contextspkg+".Service.A", unusedDefs, t)
+		assertDef(contextspkg+".Service.B", unusedDefs, t)
+
+		a := gounexport.FindDefinitionByName(defs, contextspkg+".Service.A")
+		if a == nil {
+			t.Fatal("expected Service.A definition")
+		}
+		if a.ID != contextspkg+"#Service.A" {
+			t.Errorf("expected ID %s#Service.A, but found %s", contextspkg, a.ID)
+		}
+		for _, u := range a.Usages {
+			t.Errorf("expected no usages of Service.A, but found %v", u.Pos)
+		}
+	}
+}
+
+func TestGetDefinitionsToHideModule(t *testing.T) {
+	if _, err := fs.FindModule("testdata/testmodule"); err != nil {
+		t.Fatalf("error while reading module %v", err)
+	}
+	unusedDefs := getDefinitionsToHide("example.com/testmodule", 1, t)
+
+	assertDef("example.com/testmodule.Unused", unusedDefs, t)
+}
+
+func TestGetDefinitionsToHideMultiplePackages(t *testing.T) {
+	lib := pkg + "/testmulti/lib"
+	app := pkg + "/testmulti/app"
+
+	//Lib is used only by app, so both should be analyzed together
+	getDefinitionsToHide(lib, 2, t)
+
+	//Result doesn't depend on the order of packages. libx is parsed,
+	//but it's not analyzed, even though lib is a prefix of its path.
+	for _, pkgs := range [][]string{{lib, app}, {app, lib}} {
+		defs, err := gounexport.GetPackagesDefinitions(append(pkgs, lib+"x"), nil)
+		if err != nil {
+			t.Fatalf("error while parsing packages %v", err)
+		}
+		unusedDefs := gounexport.FindUnusedDefinitionsInPackages(pkgs, defs, new(gounexport.Options))
+		if len(unusedDefs) != 1 {
+			t.Errorf("expected 1 unused exported definition in %v, but found %d", pkgs, len(unusedDefs))
+		}
+		assertDef(lib+".Unused", unusedDefs, t)
+	}
+}
+
+func TestGetDefinitionsToHideConsumers(t *testing.T) {
+	if _, err := fs.FindModule("testdata/testmodule"); err != nil {
+		t.Fatalf("error while reading module %v", err)
+	}
+	dep := "example.com/dep"
+	getDefinitionsToHide(dep, 1, t)
+
+	//Consumer is parsed only to collect usages
+	defs, err := gounexport.GetPackagesDefinitions([]string{dep, "example.com/testmodule"}, nil)
+	if err != nil {
+		t.Fatalf("error while parsing packages %v", err)
+	}
+	unusedDefs := gounexport.FindUnusedDefinitionsInPackages([]string{dep}, defs, new(gounexport.Options))
+	if len(unusedDefs) != 0 {
+		t.Errorf("expected no unused definitions, but found %d", len(unusedDefs))
+	}
+}
+
+func TestGetDefinitionsToHideKeepDirectives(t *testing.T) {
+	keeppkg := pkg + "/testkeep"
+	_, fset, info := parsePackage(keeppkg, t)
+	defs := gounexport.GetDefinitions(info, fset)
+
+	opts := new(gounexport.Options)
+	opts.Directives = gounexport.NewDirectives()
+	unusedDefs := gounexport.FindUnusedDefinitionsInPackages([]string{keeppkg}, defs, opts)
+	if len(unusedDefs) != 2 {
+		t.Errorf("expected 2 unused exported definitions, but found %d", len(unusedDefs))
+	}
+	assertDef(keeppkg+".Unused", unusedDefs, t)
+	assertDef(keeppkg+".Config.UnusedField", unusedDefs, t)
+
+	stale := opts.Directives.Stale()
+	if len(stale) != 1 {
+		t.Fatalf("expected 1 stale directive, but found %d", len(stale))
+	}
+	if !strings.HasSuffix(stale[0].Pos.Filename, "testkeep.go") || stale[0].Pos.Line != 24 {
+		t.Errorf("expected stale directive at testkeep.go:24, but found %s", stale[0].Pos)
+	}
+}
+
+func TestGetDefinitionsToHideKinds(t *testing.T) {
+	structpkg := pkg + "/teststruct"
+	_, fset, info := parsePackage(structpkg, t)
+	defs := gounexport.GetDefinitions(info, fset)
+
+	opts := new(gounexport.Options)
+	opts.Kinds = []gounexport.Kind{gounexport.KindField, gounexport.KindType}
+	unusedDefs := gounexport.FindUnusedDefinitionsInPackages([]string{structpkg}, defs, opts)
+	if len(unusedDefs) != 2 {
+		t.Errorf("expected 2 unused exported definitions, but found %d", len(unusedDefs))
+	}
+	assertDef(structpkg+".UnusedStruct", unusedDefs, t)
+	assertDef(structpkg+".UsedStruct.UnusedField", unusedDefs, t)
+
+	expected := map[string]gounexport.Kind{
+		structpkg + ".UsedStruct":              gounexport.KindType,
+		structpkg + ".UsedStruct.UsedField":    gounexport.KindField,
+		structpkg + ".UsedStruct.UnusedMethod": gounexport.KindMethod,
+	}
+	for name, kind := range expected {
+		if def := gounexport.FindDefinitionByName(defs, name); def == nil || def.Kind != kind {
+			t.Errorf("expected %s to be %s, but got %v", name, kind, def)
+		}
+	}
+}
+
+func TestGetDefinitionsToHideReflection(t *testing.T) {
+	reflectpkg := pkg + "/testreflect"
+	_, fset, info := parsePackage(reflectpkg, t)
+	defs := gounexport.GetDefinitions(info, fset)
+
+	opts := new(gounexport.Options)
+	unusedDefs := gounexport.FindUnusedDefinitionsInPackages([]string{reflectpkg}, defs, opts)
+	if len(unusedDefs) != 8 {
+		t.Errorf("expected 8 unused exported definitions, but found %d", len(unusedDefs))
+	}
+	assertDef(reflectpkg+".Tagged.Ignored", unusedDefs, t)
+	assertDef(reflectpkg+".Tagged.Plain", unusedDefs, t)
+
+	expected := map[string]string{
+		reflectpkg + ".Tagged.Name":    "json tag",
+		reflectpkg + ".Encoded.Value":  "passed to encoding/json.Marshal",
+		reflectpkg + ".Nested.Inner":   "passed to encoding/json.Marshal",
+		reflectpkg + ".Payload.Data":   "passed to encoding/json.Marshal",
+		reflectpkg + ".View.Title":     "passed to (*text/template.Template).Execute",
+		reflectpkg + ".View.Upper":     "passed to (*text/template.Template).Execute",
+		reflectpkg + ".Tagged.Ignored": "",
+	}
+	for name, reason := range expected {
+		if def := gounexport.FindDefinitionByName(defs, name); def == nil || def.Reflection != reason {
+			t.Errorf("expected %s to be reachable by [%s], but got %v", name, reason, def)
+		}
+	}
+
+	opts.IncludeReflected = true
+	unusedDefs = gounexport.FindUnusedDefinitionsInPackages([]string{reflectpkg}, defs, opts)
+	if len(unusedDefs) != 15 {
+		t.Errorf("expected 15 unused exported definitions, but found %d", len(unusedDefs))
+	}
+}
+
+func TestGetDefinitionsToHideDynamicUsages(t *testing.T) {
+	dynamicpkg := pkg + "/testdynamic"
+	_, fset, info := parsePackage(dynamicpkg, t)
+	defs := gounexport.GetDefinitions(info, fset)
+
+	opts := new(gounexport.Options)
+	unusedDefs := gounexport.FindUnusedDefinitionsInPackages([]string{dynamicpkg}, defs, opts)
+	if len(unusedDefs) != 1 {
+		t.Errorf("expected 1 unused exported definition, but found %d", len(unusedDefs))
+	}
+	assertDef(dynamicpkg+".Greeter.Unused", unusedDefs, t)
+
+	expected := map[string]string{
+		dynamicpkg + ".Greeter.Name":     "{{.Name}} in template greeting.tmpl",
+		dynamicpkg + ".Greeter.Greeting": "{{.Greeting}} in template string",
+		dynamicpkg + ".Greeter.Bye":      "{{.Bye}} in template string",
+		dynamicpkg + ".Greeter.Hello":    `(reflect.Value).MethodByName("Hello")`,
+		dynamicpkg + ".Greeter.Hidden":   `(reflect.Value).FieldByName("Hidden")`,
+	}
+	for name, evidence := range expected {
+		if !hasEvidence(gounexport.FindDefinitionByName(defs, name), evidence) {
+			t.Errorf("expected usage of %s with evidence [%s]", name, evidence)
+		}
+	}
+
+	opts.IncludeReflected = true
+	unusedDefs = gounexport.FindUnusedDefinitionsInPackages([]string{dynamicpkg}, defs, opts)
+	if len(unusedDefs) != 6 {
+		t.Errorf("expected 6 unused exported definitions, but found %d", len(unusedDefs))
+	}
+}
+
+func hasEvidence(def *gounexport.Definition, evidence string) bool {
+	if def == nil {
+		return false
+	}
+	for _, u := range def.Usages {
+		if u.Evidence == evidence {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFindDeadDefinitions(t *testing.T) {
+	deadpkg := pkg + "/testdead"
+	_, fset, info := parsePackage(deadpkg, t)
+	defs := gounexport.GetDefinitions(info, fset)
+
+	//Exported definitions of the library are API
+	opts := new(gounexport.Options)
+	deadDefs := gounexport.FindDeadDefinitions([]string{deadpkg}, defs, opts)
+	if len(deadDefs) != 3 {
+		t.Errorf("expected 3 dead definitions, but found %d", len(deadDefs))
+	}
+	assertDef(deadpkg+".Console.unusedField", deadDefs, t)
+	assertDef(deadpkg+".unusedVar", deadDefs, t)
+	assertDef(deadpkg+"/main.Exported", deadDefs, t)
+	for _, def := range deadDefs {
+		if def.Name == deadpkg+".Live" || def.Name == deadpkg+".Unused" {
+			t.Errorf("expected %s to be not reported without IncludeAPI", def.Name)
+		}
+	}
+
+	opts.Transitive = true
+	deadDefs = gounexport.FindDeadDefinitions([]string{deadpkg}, defs, opts)
+	if len(deadDefs) != 3 {
+		t.Errorf("expected 3 dead definitions in transitive mode, but found %d", len(deadDefs))
+	}
+
+	opts.Transitive = false
+	opts.IncludeAPI = true
+	deadDefs = gounexport.FindDeadDefinitions([]string{deadpkg}, defs, opts)
+	if len(deadDefs) != 5 {
+		t.Errorf("expected 5 dead definitions with API, but found %d", len(deadDefs))
+	}
+	assertDef(deadpkg+".Live", deadDefs, t)
+	assertDef(deadpkg+".Unused", deadDefs, t)
+
+	opts.Transitive = true
+	deadDefs = gounexport.FindDeadDefinitions([]string{deadpkg}, defs, opts)
+	if len(deadDefs) != 8 {
+		t.Errorf("expected 8 dead definitions in transitive mode with API, but found %d", len(deadDefs))
+	}
+	assertDef(deadpkg+".onlyFromUnused", deadDefs, t)
+	assertDef(deadpkg+".deeper", deadDefs, t)
+	assertDef(deadpkg+".unusedConst", deadDefs, t)
+}
+
+func TestFindUnreachableDefinitions(t *testing.T) {
+	reachpkg := pkg + "/testreach"
+	_, fset, info := parsePackage(reachpkg, t)
+	defs := gounexport.GetDefinitions(info, fset)
+
+	opts := new(gounexport.Options)
+	unreachable := gounexport.FindUnreachableDefinitions([]string{reachpkg}, defs, opts)
+	if len(unreachable) != 6 {
+		t.Errorf("expected 6 unreachable definitions, but found %d", len(unreachable))
+	}
+	assertDef(reachpkg+".Server.unused", unreachable, t)
+	assertDef(reachpkg+".ping", unreachable, t)
+	assertDef(reachpkg+".pong", unreachable, t)
+	assertDef(reachpkg+".Cluster", unreachable, t)
+	assertDef(reachpkg+".clusterHelper", unreachable, t)
+	assertDef(reachpkg+".registry", unreachable, t)
+
+	opts.APIRoots = true
+	unreachable = gounexport.FindUnreachableDefinitions([]string{reachpkg}, defs, opts)
+	if len(unreachable) != 5 {
+		t.Errorf("expected 5 unreachable definitions with API roots, but found %d", len(unreachable))
+	}
+	assertDef(reachpkg+".clusterHelper", unreachable, t)
+
+	opts.APIRoots = false
+	opts.Roots = []*regexp.Regexp{regexp.MustCompile(`\.registry$`)}
+	unreachable = gounexport.FindUnreachableDefinitions([]string{reachpkg}, defs, opts)
+	if len(unreachable) != 3 {
+		t.Errorf("expected 3 unreachable definitions with registry root, but found %d", len(unreachable))
+	}
+	assertDef(reachpkg+".Server.unused", unreachable, t)
+	assertDef(reachpkg+".Cluster", unreachable, t)
+	assertDef(reachpkg+".clusterHelper", unreachable, t)
+}
+
+func TestParseKinds(t *testing.T) {
+	kinds, err := gounexport.ParseKinds([]string{"func", " interfacemethod"})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if len(kinds) != 2 || kinds[0] != gounexport.KindFunc || kinds[1] != gounexport.KindInterfaceMethod {
+		t.Errorf("unexpected kinds %v", kinds)
+	}
+	if _, err := gounexport.ParseKinds([]string{"function"}); err == nil {
+		t.Errorf("expected error for unknown kind")
+	}
+}
+
 func getDefinitionsToHide(pkg string, expectedLen int, t *testing.T) []*gounexport.Definition {
 	return getDefinitionsToHideWithExclusions(pkg, expectedLen, nil, t)
 }
@@ -82,8 +489,8 @@ func TestGetDefinitionsToHideThis(t *testing.T) {
 	}
 	log.Print("<<<<<<<<<<<<<<<<<<<<<<<<<<<")
 
-	if len(unusedDefs) != 22 {
-		t.Errorf("expected %d unused exported definitions, but found %d", 22, len(unusedDefs))
+	if len(unusedDefs) != 103 {
+		t.Errorf("expected %d unused exported definitions, but found %d", 103, len(unusedDefs))
 	}
 }
 
@@ -165,6 +572,141 @@ func TestUnexport(t *testing.T) {
 	assertRename(renamesCount, "UsedInPackageMethod", 2, t)
 }
 
+func TestUnexportShadowing(t *testing.T) {
+	_, fset, info := parsePackage(pkg+"/testshadow", t)
+	defs := gounexport.GetDefinitions(info, fset)
+	unusedDefs := gounexport.FindUnusedDefinitions(pkg, defs, nil)
+
+	renamesCount := make(map[string]int)
+	renameFunc := func(file string, offset int, source string, target string) error {
+		renamesCount[source] = renamesCount[source] + 1
+		return nil
+	}
+
+	conflicts := map[string]bool{
+		"Type":   true,
+		"String": true,
+		"Json":   true,
+		"Count":  true,
+		"Total":  true,
+		"Name":   true,
+		"Foo":    true,
+		"Field":  true,
+	}
+	for _, d := range unusedDefs {
+		err := gounexport.Unexport(d, defs, renameFunc)
+		if conflicts[d.SimpleName] && err == nil {
+			t.Errorf("expected conflict error for %s", d.SimpleName)
+		}
+		if !conflicts[d.SimpleName] && err != nil {
+			t.Errorf("unexpected error for %s: %v", d.SimpleName, err)
+		}
+	}
+
+	for name := range conflicts {
+		assertRename(renamesCount, name, 0, t)
+	}
+	assertRename(renamesCount, "Safe", 1, t)
+}
+
+func TestUnexportWithNaming(t *testing.T) {
+	_, fset, info := parsePackage(pkg+"/testnaming", t)
+	defs := gounexport.GetDefinitions(info, fset)
+	unusedDefs := gounexport.FindUnusedDefinitions(pkg, defs, nil)
+
+	naming := gounexport.NewNaming()
+	naming.FallbackSuffix = "_"
+	naming.FallbackPrefix = "x"
+	mapping, err := gounexport.ReadNamingMapping("testdata/testnaming/names.txt")
+	if err != nil {
+		t.Fatalf("error while reading names: %v", err)
+	}
+	naming.Mapping = mapping
+
+	renames := make(map[string]string)
+	renameFunc := func(file string, offset int, source string, target string) error {
+		renames[source] = target
+		return nil
+	}
+	for _, d := range unusedDefs {
+		if err := gounexport.UnexportWithNaming(d, defs, naming, renameFunc); err != nil {
+			t.Errorf("unexpected error for %s: %v", d.SimpleName, err)
+		}
+	}
+
+	expected := map[string]string{
+		"HTTPClient":  "httpClient",
+		"ID":          "id",
+		"HTTP2Server": "http2Server",
+		"Type":        "type_",
+		"Limit":       "xLimit",
+		"URL":         "baseURL",
+		"Port":        "listenPort",
+	}
+	for source, target := range expected {
+		if renames[source] != target {
+			t.Errorf("expected [%s] to be renamed to [%s], but was [%s]", source, target, renames[source])
+		}
+	}
+	//The first renamed one gets the preferred name
+	jsonNames := []string{renames["JSONData"], renames["JsonData"]}
+	sort.Strings(jsonNames)
+	if jsonNames[0] != "jsonData" || jsonNames[1] != "jsonData_" {
+		t.Errorf("expected jsonData and jsonData_, but found %v", jsonNames)
+	}
+}
+
+func TestNamingInitialisms(t *testing.T) {
+	tests := map[string]string{
+		"Foo":            "foo",
+		"ID":             "id",
+		"IDs":            "ids",
+		"URLs":           "urls",
+		"HTTPClient":     "httpClient",
+		"HTTP2Server":    "http2Server",
+		"HTTPSServer":    "httpsServer",
+		"HTTPSomething":  "httpSomething",
+		"XMLHTTPRequest": "xmlHTTPRequest",
+		"JSONAPIs":       "jsonAPIs",
+		"UserIDs":        "userIDs",
+		"ServerURLs":     "serverURLs",
+		"IPAddress":      "ipAddress",
+		"ABCThing":       "aBCThing",
+		"Élan":           "élan",
+	}
+	for source, expected := range tests {
+		def := new(gounexport.Definition)
+		def.Name = pkg + "." + source
+		def.SimpleName = source
+
+		var newName string
+		renameFunc := func(file string, offset int, source string, target string) error {
+			newName = target
+			return nil
+		}
+		if err := gounexport.UnexportWithNaming(def, nil, gounexport.NewNaming(), renameFunc); err != nil {
+			t.Errorf("unexpected error for %s: %v", source, err)
+		}
+		if newName != expected {
+			t.Errorf("expected [%s] to be renamed to [%s], but was [%s]", source, expected, newName)
+		}
+	}
+}
+
+func TestReadNamingMapping(t *testing.T) {
+	file, err := ioutil.TempFile("", "names")
+	if err != nil {
+		t.Fatalf("error while creating file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("ID Ident\n")
+	file.Close()
+
+	if _, err := gounexport.ReadNamingMapping(file.Name()); err == nil {
+		t.Error("expected error for exported new name")
+	}
+}
+
 func assertRename(renamesCount map[string]int, name string, expected int, t *testing.T) {
 	if renamesCount[name] != expected {
 		t.Errorf("expected [%d] renames of [%s], but was [%d]", expected, name, renamesCount[name])
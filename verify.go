@@ -0,0 +1,75 @@
+package gounexport
+
+import (
+	"go/build"
+	"go/types"
+
+	"github.com/dooman87/gounexport/util"
+)
+
+//NewTypeErrors returns errors from after list that are not presented
+//in before list. Errors are matched by position and message first. Edits
+//are moving lines and columns of existing errors, so errors that are not
+//matched by position are matched by file and message. Each error from before list
+//is matched only once, so a new error with the same message in the same
+//file is still reported.
+func NewTypeErrors(before []types.Error, after []types.Error) TypeErrors {
+	byPosition := make(map[string]int, len(before))
+	byMessage := make(map[string]int, len(before))
+	for _, e := range before {
+		byPosition[typeErrorPosition(e)]++
+		byMessage[typeErrorMessage(e)]++
+	}
+
+	var unmatched []types.Error
+	for _, e := range after {
+		if pos := typeErrorPosition(e); byPosition[pos] > 0 {
+			byPosition[pos]--
+			byMessage[typeErrorMessage(e)]--
+			continue
+		}
+		unmatched = append(unmatched, e)
+	}
+
+	var result TypeErrors
+	for _, e := range unmatched {
+		if msg := typeErrorMessage(e); byMessage[msg] > 0 {
+			byMessage[msg]--
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+//Verify type checks packages after changes and returns errors
+//that were not presented before. before are type errors that were
+//found before changes, see TypeCheck.
+func Verify(pkgNames []string, contexts []*build.Context, before []types.Error) (TypeErrors, error) {
+	after, err := TypeCheck(pkgNames, contexts)
+	if err != nil {
+		return nil, err
+	}
+
+	newErrors := NewTypeErrors(before, after)
+	for _, e := range newErrors {
+		util.Err("new type error after changes: %v", e)
+	}
+	return newErrors, nil
+}
+
+//typeErrorMessage returns file and message of the error
+func typeErrorMessage(e types.Error) string {
+	if e.Fset == nil {
+		return e.Msg
+	}
+	return e.Fset.Position(e.Pos).Filename + ": " + e.Msg
+}
+
+//typeErrorPosition returns position and message of the error
+func typeErrorPosition(e types.Error) string {
+	if e.Fset == nil {
+		return e.Msg
+	}
+	return e.Fset.Position(e.Pos).String() + ": " + e.Msg
+}
@@ -0,0 +1,239 @@
+package gounexport_test
+
+import (
+	"go/build"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dooman87/gounexport"
+	"github.com/dooman87/gounexport/edit"
+	"github.com/dooman87/gounexport/fs"
+)
+
+const verifySource = `package verify
+
+type Inner struct{}
+
+func (i *Inner) Foo() int {
+	return 1
+}
+
+type Outer struct {
+	Inner
+	foo int
+}
+
+func Bar(o *Outer) int {
+	return o.Foo() + o.foo
+}
+`
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gounexport-verify")
+	if err != nil {
+		t.Fatalf("error while creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "verify.go")
+	writeTestFile(filepath.Join(dir, "go.mod"), "module example.com/verify\n", t)
+	writeTestFile(file, verifySource, t)
+	if _, err := fs.FindModule(dir); err != nil {
+		t.Fatalf("error while reading module %v", err)
+	}
+
+	pkg := "example.com/verify"
+	contexts := []*build.Context{&build.Default}
+	before, err := gounexport.TypeCheck([]string{pkg}, contexts)
+	if err != nil {
+		t.Fatalf("error while type checking %v", err)
+	}
+	if len(before) != 0 {
+		t.Fatalf("expected no type errors before renaming, but found %v", before)
+	}
+
+	//Outer.foo field shadows promoted Inner.foo method. Unexport
+	//refuses such renaming, so edits are added by hand.
+	set := edit.NewSet()
+	for _, offset := range []int{strings.Index(verifySource, "Foo"), strings.LastIndex(verifySource, "Foo")} {
+		if err := set.Add(file, offset, "Foo", "foo"); err != nil {
+			t.Fatalf("error while adding edit %v", err)
+		}
+	}
+	if err := set.Commit(); err != nil {
+		t.Fatalf("error while committing %v", err)
+	}
+
+	newErrors, err := gounexport.Verify([]string{pkg}, contexts, before)
+	if err != nil {
+		t.Fatalf("error while verifying %v", err)
+	}
+	if len(newErrors) != 1 {
+		t.Errorf("expected 1 new type error, but found %v", newErrors)
+	}
+
+	if err := set.Rollback(); err != nil {
+		t.Fatalf("error while rolling back %v", err)
+	}
+	content, _ := ioutil.ReadFile(file)
+	if string(content) != verifySource {
+		t.Errorf("expected original content after rollback, but found\n%s", content)
+	}
+}
+
+const verifyShiftedSource = `package verify
+
+func Long() int {
+	return 1
+}
+
+func use() (int, string) { return Long(), 1 }
+`
+
+func TestVerifyExistingErrorShifted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gounexport-verify")
+	if err != nil {
+		t.Fatalf("error while creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestFile(filepath.Join(dir, "go.mod"), "module example.com/verify\n", t)
+	writeTestFile(filepath.Join(dir, "verify.go"), verifyShiftedSource, t)
+	if _, err := fs.FindModule(dir); err != nil {
+		t.Fatalf("error while reading module %v", err)
+	}
+
+	pkg := "example.com/verify"
+	contexts := []*build.Context{&build.Default}
+	before, err := gounexport.TypeCheck([]string{pkg}, contexts)
+	if err != nil {
+		t.Fatalf("error while type checking %v", err)
+	}
+	if len(before) != 1 {
+		t.Fatalf("expected 1 type error before renaming, but found %v", before)
+	}
+
+	//Existing error is moved to the right by the longer name
+	file := filepath.Join(dir, "verify.go")
+	set := edit.NewSet()
+	for _, offset := range []int{strings.Index(verifyShiftedSource, "Long"), strings.LastIndex(verifyShiftedSource, "Long")} {
+		if err := set.Add(file, offset, "Long", "longerName"); err != nil {
+			t.Fatalf("error while adding edit %v", err)
+		}
+	}
+	if err := set.Commit(); err != nil {
+		t.Fatalf("error while committing %v", err)
+	}
+	defer set.Rollback()
+
+	newErrors, err := gounexport.Verify([]string{pkg}, contexts, before)
+	if err != nil {
+		t.Fatalf("error while verifying %v", err)
+	}
+	if len(newErrors) != 0 {
+		t.Errorf("expected no new type errors, but found %v", newErrors)
+	}
+}
+
+const verifyRemoveSource = `package verify
+
+import "strings"
+
+func dead() string {
+	return strings.ToUpper("dead")
+}
+
+func broken() string {
+	return 1
+}
+`
+
+func TestVerifyExistingErrorAfterRemove(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gounexport-verify")
+	if err != nil {
+		t.Fatalf("error while creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "verify.go")
+	writeTestFile(filepath.Join(dir, "go.mod"), "module example.com/verify\n", t)
+	writeTestFile(file, verifyRemoveSource, t)
+	if _, err := fs.FindModule(dir); err != nil {
+		t.Fatalf("error while reading module %v", err)
+	}
+
+	pkg := "example.com/verify"
+	contexts := []*build.Context{&build.Default}
+	before, err := gounexport.TypeCheck([]string{pkg}, contexts)
+	if err != nil {
+		t.Fatalf("error while type checking %v", err)
+	}
+	if len(before) != 1 {
+		t.Fatalf("expected 1 type error before removing, but found %v", before)
+	}
+
+	//Package with type errors can't be parsed, so the
+	//definition is created by hand
+	dead := new(gounexport.Definition)
+	dead.Name = pkg + ".dead"
+	dead.SimpleName = "dead"
+	dead.File = file
+	dead.Offset = strings.Index(verifyRemoveSource, "dead()")
+
+	//Existing error is moved up by removed function and import
+	set := edit.NewSet()
+	set.AddFormatter(gounexport.TidyFile)
+	if err := gounexport.Remove(dead, set.Add); err != nil {
+		t.Fatalf("error while removing %v", err)
+	}
+	if err := set.Commit(); err != nil {
+		t.Fatalf("error while committing %v", err)
+	}
+	defer set.Rollback()
+
+	newErrors, err := gounexport.Verify([]string{pkg}, contexts, before)
+	if err != nil {
+		t.Fatalf("error while verifying %v", err)
+	}
+	if len(newErrors) != 0 {
+		t.Errorf("expected no new type errors, but found %v", newErrors)
+	}
+	content, _ := ioutil.ReadFile(file)
+	if strings.Contains(string(content), "dead") || strings.Contains(string(content), "strings") {
+		t.Errorf("expected dead function and its import to be removed, but found\n%s", content)
+	}
+}
+
+func TestNewTypeErrorsEmpty(t *testing.T) {
+	if errs := gounexport.NewTypeErrors(nil, nil); len(errs) != 0 {
+		t.Errorf("expected no new errors, but found %v", errs)
+	}
+}
+
+func TestNewTypeErrorsSamePosition(t *testing.T) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("verify.go", -1, 100)
+	file.SetLines([]int{0, 50})
+	pos := file.Pos(60)
+
+	before := []types.Error{{Fset: fset, Pos: pos, Msg: "declared and not used: x"}}
+	after := []types.Error{
+		{Fset: fset, Pos: pos, Msg: "undefined: Foo"},
+		{Fset: fset, Pos: pos, Msg: "declared and not used: x"},
+	}
+	errs := gounexport.NewTypeErrors(before, after)
+	if len(errs) != 1 || errs[0].Msg != "undefined: Foo" {
+		t.Errorf("expected only [undefined: Foo] to be new, but found %v", errs)
+	}
+}
+
+func writeTestFile(file string, content string, t *testing.T) {
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("error while writing %s: %v", file, err)
+	}
+}